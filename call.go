@@ -0,0 +1,171 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Call - Channel of outbound connection with blocking call control verbs.
+// Every verb waits until application completes and returns ErrorChannelHangup if caller hangs up meanwhile.
+type Call struct {
+	conn *ESLConnection
+}
+
+// NewCall - Will bind call to outbound connection. Subscribes connection to its channel events if needed.
+// Verbs don't need Messages() to be read, channel events still come from it (or from OnEvent, OnChannel...
+// handlers of the connection). Events nobody takes stay buffered, up to MaxEventBacklog while verb waits
+func NewCall(ctx context.Context, conn *ESLConnection) (*Call, error) {
+	if !conn.Subscriptions().MyEvents {
+		if err := conn.MyEvents(ctx, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Call{conn: conn}, nil
+}
+
+// UUID - Will return channel UUID
+func (c *Call) UUID() string {
+	return c.conn.ChannelData().UniqueID()
+}
+
+// Connection - Will return connection call is bound to
+func (c *Call) Connection() *ESLConnection {
+	return c.conn
+}
+
+// execute runs application and turns -ERR response into error
+func (c *Call) execute(ctx context.Context, app, args string) (*ExecuteResult, error) {
+	result, err := c.conn.ExecuteAndWait(ctx, app, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(result.Response(), "-ERR") {
+		return result, newErrorApplicationFailed(app, result.Response())
+	}
+
+	return result, nil
+}
+
+// Answer - Will answer the call
+func (c *Call) Answer(ctx context.Context) error {
+	_, err := c.execute(ctx, "answer", "")
+	return err
+}
+
+// PreAnswer - Will establish early media without answering
+func (c *Call) PreAnswer(ctx context.Context) error {
+	_, err := c.execute(ctx, "pre_answer", "")
+	return err
+}
+
+// Playback - Will play file to the caller
+func (c *Call) Playback(ctx context.Context, file string) error {
+	result, err := c.execute(ctx, "playback", file)
+	if err != nil {
+		return err
+	}
+
+	if response := result.Response(); response != "FILE PLAYED" {
+		return newErrorApplicationFailed("playback", response)
+	}
+	return nil
+}
+
+// Speak - Will say text with tts engine and voice
+func (c *Call) Speak(ctx context.Context, engine, voice, text string) error {
+	_, err := c.execute(ctx, "speak", engine+"|"+voice+"|"+text)
+	return err
+}
+
+// Record - Will record caller to path. Zero limit records until silence or hangup
+func (c *Call) Record(ctx context.Context, path string, limit time.Duration) error {
+	args := path
+	if seconds := int(limit / time.Second); seconds > 0 {
+		args += " " + strconv.Itoa(seconds)
+	}
+
+	_, err := c.execute(ctx, "record", args)
+	return err
+}
+
+// Bridge - Will bridge call to dial string and return when bridge ends
+func (c *Call) Bridge(ctx context.Context, dialString string) error {
+	result, err := c.execute(ctx, "bridge", dialString)
+	if err != nil {
+		return err
+	}
+
+	if disposition := result.Variable("originate_disposition"); disposition != "" && disposition != "SUCCESS" {
+		return newErrorApplicationFailed("bridge", disposition)
+	}
+	return nil
+}
+
+// DefaultDialplan is dialplan Transfer uses when only context is given
+const DefaultDialplan = "XML"
+
+// Transfer - Will transfer call to extension. Empty dialplan and context use channel defaults,
+// dialplan defaults to DefaultDialplan when only context is given
+func (c *Call) Transfer(ctx context.Context, extension, dialplan, dialplanContext string) error {
+	if dialplan == "" && dialplanContext != "" {
+		dialplan = DefaultDialplan
+	}
+
+	_, err := c.execute(ctx, "transfer", strings.TrimSpace(strings.Join([]string{extension, dialplan, dialplanContext}, " ")))
+	return err
+}
+
+// Park - Will park the call
+func (c *Call) Park(ctx context.Context) error {
+	_, err := c.execute(ctx, "park", "")
+	return err
+}
+
+// Sleep - Will pause call processing for duration
+func (c *Call) Sleep(ctx context.Context, duration time.Duration) error {
+	_, err := c.execute(ctx, "sleep", strconv.FormatInt(int64(duration/time.Millisecond), 10))
+	return err
+}
+
+// SetVar - Will set channel variable
+func (c *Call) SetVar(ctx context.Context, name, value string) error {
+	_, err := c.execute(ctx, "set", name+"="+value)
+	return err
+}
+
+// GetVar - Will return channel variable, or "" if it is not set
+func (c *Call) GetVar(ctx context.Context, name string) (string, error) {
+	msg, err := c.conn.Api(ctx, "uuid_getvar "+c.UUID()+" "+name)
+	if err != nil {
+		return "", err
+	}
+
+	value := strings.TrimSpace(string(msg.Body))
+	if value == "_undef_" {
+		return "", nil
+	}
+	return value, nil
+}
+
+// Hangup - Will hang up the call with cause. Empty cause means NORMAL_CLEARING
+func (c *Call) Hangup(ctx context.Context, cause string) error {
+	_, err := c.execute(ctx, "hangup", cause)
+
+	var hangup *ErrorChannelHangup
+	if errors.As(err, &hangup) || errors.Is(err, ErrConnectionClosed) {
+		// channel is gone, that's what we asked for
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,157 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/PSyton/goesl"
+	"github.com/PSyton/goesl/goesltest"
+)
+
+// serveOutbound starts outbound server running handler. Server must be stopped by caller
+func serveOutbound(t *testing.T, opts goesl.ServerOptions, handler goesl.HandlerFunc) *goesl.ESLServer {
+	t.Helper()
+
+	srv := goesl.NewESLServerWithOptions(opts)
+	if err := srv.Start("127.0.0.1:0", handler); err != nil {
+		t.Fatal(err)
+	}
+
+	return srv
+}
+
+// runCall dials outbound server with fake channel and returns error of the call flow
+func runCall(t *testing.T, flow func(ctx context.Context, call *goesl.Call) error, script func(fake *goesltest.Call)) (*goesltest.Call, error) {
+	t.Helper()
+
+	result := make(chan error, 1)
+	srv := serveOutbound(t, goesl.ServerOptions{}, func(conn *goesl.ESLConnection) bool {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		call, err := goesl.NewCall(ctx, conn)
+		if err == nil {
+			err = flow(ctx, call)
+		}
+		result <- err
+		return true
+	})
+
+	defer srv.Stop()
+
+	fake, err := goesltest.DialOutbound(srv.Addr(), map[string]string{"Unique-ID": "call-uuid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fake.Close()
+
+	if script != nil {
+		script(fake)
+	}
+
+	select {
+	case err = <-result:
+	case <-time.After(10 * time.Second):
+		t.Fatal("call flow didn't finish")
+	}
+	return fake, err
+}
+
+func TestCallStraightLineWithDefaultOptions(t *testing.T) {
+	fake, err := runCall(t, func(ctx context.Context, call *goesl.Call) error {
+		if err := call.Answer(ctx); err != nil {
+			return err
+		}
+		if err := call.SetVar(ctx, "foo", "bar"); err != nil {
+			return err
+		}
+		if err := call.Playback(ctx, "/tmp/hello.wav"); err != nil {
+			return err
+		}
+		if err := call.Transfer(ctx, "1000", "", "default"); err != nil {
+			return err
+		}
+		return call.Hangup(ctx, "")
+	}, nil)
+	if err != nil {
+		t.Fatalf("call flow failed: %v", err)
+	}
+
+	expected := []struct{ app, args string }{
+		{"answer", ""},
+		{"set", "foo=bar"},
+		{"playback", "/tmp/hello.wav"},
+		{"transfer", "1000 XML default"},
+		{"hangup", ""},
+	}
+	executions := fake.Executions()
+	if len(executions) != len(expected) {
+		t.Fatalf("expected %d executions, got %+v", len(expected), executions)
+	}
+	for i, e := range expected {
+		if executions[i].App != e.app || executions[i].Args != e.args {
+			t.Errorf("execution %d: expected %s(%q), got %s(%q)", i, e.app, e.args, executions[i].App, executions[i].Args)
+		}
+	}
+}
+
+func TestCallCallerHangsUp(t *testing.T) {
+	hangupErr := make(chan error, 1)
+	_, err := runCall(t, func(ctx context.Context, call *goesl.Call) error {
+		err := call.Playback(ctx, "/tmp/long.wav")
+		hangupErr <- call.Hangup(ctx, "")
+		return err
+	}, func(fake *goesltest.Call) {
+		fake.SetAppDelay("playback", time.Second)
+		time.Sleep(100 * time.Millisecond)
+		fake.Hangup("NORMAL_CLEARING")
+	})
+
+	var hangup *goesl.ErrorChannelHangup
+	if !errors.As(err, &hangup) {
+		t.Fatalf("expected ErrorChannelHangup, got %v", err)
+	}
+	if hangup.Cause != "NORMAL_CLEARING" {
+		t.Errorf("expected NORMAL_CLEARING cause, got %q", hangup.Cause)
+	}
+	if err := <-hangupErr; err != nil {
+		t.Errorf("hangup of gone channel should succeed, got %v", err)
+	}
+}
+
+func TestCallLeavesEventsToConnection(t *testing.T) {
+	_, err := runCall(t, func(ctx context.Context, call *goesl.Call) error {
+		if err := call.Answer(ctx); err != nil {
+			return err
+		}
+
+		// events arrived while verb waited are still delivered to Messages()
+		var names []goesl.EventName
+		for len(names) < 2 {
+			select {
+			case msg := <-call.Connection().Messages():
+				if ev, err := msg.AsEvent(); err == nil {
+					names = append(names, ev.Name())
+				}
+			case <-ctx.Done():
+				return fmt.Errorf("got events %v: %v", names, ctx.Err())
+			}
+		}
+		if names[0] != goesl.EventChannelExecute || names[1] != goesl.EventChannelAnswer {
+			return fmt.Errorf("unexpected events %v", names)
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		t.Fatalf("call flow failed: %v", err)
+	}
+}
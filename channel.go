@@ -0,0 +1,67 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl
+
+import "strings"
+
+// ChannelData - Channel headers FreeSWITCH sends to outbound connection in reply to connect
+type ChannelData struct {
+	*Message
+}
+
+// UniqueID - Will return channel UUID
+func (d *ChannelData) UniqueID() string {
+	return d.GetHeader("Unique-Id")
+}
+
+// CoreUUID - Will return Core-UUID of FreeSWITCH instance that owns the channel
+func (d *ChannelData) CoreUUID() string {
+	return d.GetHeader("Core-Uuid")
+}
+
+// ChannelName - Will return channel name, like sofia/internal/1000@127.0.0.1
+func (d *ChannelData) ChannelName() string {
+	return d.GetHeader("Channel-Name")
+}
+
+// CallerIDName - Will return caller id name
+func (d *ChannelData) CallerIDName() string {
+	return d.GetHeader("Caller-Caller-Id-Name")
+}
+
+// CallerIDNumber - Will return caller id number
+func (d *ChannelData) CallerIDNumber() string {
+	return d.GetHeader("Caller-Caller-Id-Number")
+}
+
+// DestinationNumber - Will return dialed number
+func (d *ChannelData) DestinationNumber() string {
+	return d.GetHeader("Caller-Destination-Number")
+}
+
+// DialplanContext - Will return dialplan context the call came in
+func (d *ChannelData) DialplanContext() string {
+	return d.GetHeader("Caller-Context")
+}
+
+// Direction - Will return call direction
+func (d *ChannelData) Direction() CallDirection {
+	if v := d.GetHeader("Call-Direction"); v != "" {
+		return CallDirection(v)
+	}
+	return CallDirection(d.GetHeader("Caller-Direction"))
+}
+
+// AnswerState - Will return answer state, like ringing or answered
+func (d *ChannelData) AnswerState() string {
+	return d.GetHeader("Answer-State")
+}
+
+// Variable - Will return channel variable (variable_<name> header)
+func (d *ChannelData) Variable(name string) string {
+	return d.GetHeader("Variable_" + strings.ToLower(name))
+}
@@ -0,0 +1,66 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PSyton/goesl"
+	"github.com/PSyton/goesl/goesltest"
+)
+
+func TestChannelDataFromConnectReply(t *testing.T) {
+	data := make(chan *goesl.ChannelData, 1)
+	srv := serveOutbound(t, goesl.ServerOptions{}, func(conn *goesl.ESLConnection) bool {
+		data <- conn.ChannelData()
+		return true
+	})
+	defer srv.Stop()
+
+	call, err := goesltest.DialOutbound(srv.Addr(), map[string]string{
+		"Unique-ID":                 "uuid-1",
+		"Caller-Caller-ID-Name":     "Zoë O'Brien & Co",
+		"Caller-Caller-ID-Number":   "+15551234567",
+		"Caller-Destination-Number": "*98#",
+		"Caller-Context":            "public",
+		"Answer-State":              "early",
+		"variable_sip_from_display": "100% \"Sales\"",
+		"variable_literal":          "%41 stays escaped",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer call.Close()
+
+	var channel *goesl.ChannelData
+	select {
+	case channel = <-data:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler wasn't called")
+	}
+
+	for name, check := range map[string][2]string{
+		"UniqueID":          {channel.UniqueID(), "uuid-1"},
+		"CoreUUID":          {channel.CoreUUID(), "6d9f3ab2-4e3a-4a35-9d0c-5b4f1b1f0c11"},
+		"ChannelName":       {channel.ChannelName(), "sofia/internal/1000@127.0.0.1"},
+		"CallerIDName":      {channel.CallerIDName(), "Zoë O'Brien & Co"},
+		"CallerIDNumber":    {channel.CallerIDNumber(), "+15551234567"},
+		"DestinationNumber": {channel.DestinationNumber(), "*98#"},
+		"DialplanContext":   {channel.DialplanContext(), "public"},
+		"Direction":         {string(channel.Direction()), "inbound"},
+		"AnswerState":       {channel.AnswerState(), "early"},
+		"sip_from_user":     {channel.Variable("sip_from_user"), "1000"},
+		"sip_from_display":  {channel.Variable("SIP_FROM_DISPLAY"), "100% \"Sales\""},
+		"literal":           {channel.Variable("literal"), "%41 stays escaped"},
+		"missing":           {channel.Variable("missing"), ""},
+	} {
+		if check[0] != check[1] {
+			t.Errorf("%s: expected %q, got %q", name, check[1], check[0])
+		}
+	}
+}
@@ -7,8 +7,13 @@
 package goesl
 
 import (
+	"context"
+	"crypto/tls"
+	"io"
 	"net"
+	"net/textproto"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -20,16 +25,61 @@ type Client struct {
 
 // ConnectOptions represent a cinitial connection options
 type ConnectOptions struct {
-	Host        string
-	Port        uint
-	Password    string
+	Host     string
+	Port     uint
+	Password string
+	// ESL user as user@domain. When set, userauth is used instead of auth
+	User        string
 	DialTimeout time.Duration
+	// Limits every write to the connection. Zero means no limit
+	WriteTimeout time.Duration
+	// When set, whole session is recorded there as frames (see NewRecordingConn)
+	Recorder io.Writer
+	// When set, connection is made over TLS. ServerName defaults to Host
+	TLSConfig *tls.Config
+	// Used to establish connection instead of net.Dialer. DialTimeout still applies
+	Dialer Dialer
+	// TCP keepalive period. Zero means DefaultKeepAlivePeriod, negative disables keepalive.
+	// Ignored when connection is not TCP one
+	KeepAlive time.Duration
+	// Size of Messages() buffer. Zero means unbuffered for OverflowBlock and DefaultEventBufferSize otherwise
+	EventBuffer int
+	// What to do when Messages() buffer is full. Blocks reading by default
+	EventOverflow OverflowPolicy
 }
 
-func (c *Client) authenticate(password string) error {
+// readAuthMessage reads message during authentication, turning text/rude-rejection into error
+func (c *Client) readAuthMessage() (textproto.MIMEHeader, error) {
 	m, err := c.textreader.ReadMIMEHeader()
 	if err != nil && err.Error() != "EOF" {
-		return newErrorReadMIMEHeaders(err)
+		return nil, newErrorReadMIMEHeaders(err)
+	}
+
+	if m.Get("Content-Type") != "text/rude-rejection" {
+		return m, nil
+	}
+
+	var body []byte
+	if length, err := strconv.Atoi(m.Get("Content-Length")); err == nil && length > 0 {
+		body = make([]byte, length)
+		n, _ := io.ReadFull(c.reader, body)
+		body = body[:n]
+	}
+
+	logger.Error(eRudeRejection, string(body))
+	return nil, newErrorRudeRejection(strings.TrimSpace(string(body)))
+}
+
+func (c *Client) authenticate(ctx context.Context, user, password string) (err error) {
+	stop := watchContext(ctx, time.Time{}, c.connection.SetDeadline)
+	defer func() {
+		stop()
+		err = contextError(ctx, err)
+	}()
+
+	m, err := c.readAuthMessage()
+	if err != nil {
+		return err
 	}
 
 	cType := m.Get("Content-Type")
@@ -38,32 +88,67 @@ func (c *Client) authenticate(password string) error {
 		return newErrorUnexpectedAuthHeader(cType)
 	}
 
-	err = c.Send("auth " + password)
+	cmd := "auth " + password
+	if user != "" {
+		cmd = "userauth " + user + ":" + password
+	}
+	if strings.ContainsAny(cmd, "\r\n") {
+		return newErrorInvalidCommand(cmd)
+	}
+
+	// reply is read here directly, so don't reserve reply slot for it
+	err = c.writeString(cmd + "\r\n\r\n")
 	if err != nil {
 		return err
 	}
 
-	m, err = c.textreader.ReadMIMEHeader()
-	if err != nil && err.Error() != "EOF" {
-		return newErrorReadMIMEHeaders(err)
+	m, err = c.readAuthMessage()
+	if err != nil {
+		return err
 	}
 
-	if m.Get("Reply-Text") != "+OK accepted" {
-		logger.Error(invalidPassword)
-		return newErrorInvalidPassword()
+	reply := m.Get("Reply-Text")
+	switch {
+	case strings.HasPrefix(reply, "+OK"):
+		return nil
+	case strings.HasPrefix(reply, "-ERR"):
+		reason := strings.TrimSpace(strings.TrimPrefix(reply, "-ERR"))
+		logger.Error(eAuthRejected, reason)
+		return newErrorAuthRejected(reason)
 	}
 
-	return nil
+	logger.Error(invalidPassword)
+	return newErrorInvalidPassword()
 }
 
 // NewClient - Will initiate new client that will establish connection and attempt to authenticate
 // against connected freeswitch server
 func NewClient(aOpts ConnectOptions) (*Client, error) {
+	return DialContext(context.Background(), aOpts)
+}
 
-	// host string, port uint, passwd string, timeout int
-
+// DialContext - Same as NewClient but dial and authentication are aborted when ctx is done
+func DialContext(ctx context.Context, aOpts ConnectOptions) (*Client, error) {
 	address := net.JoinHostPort(aOpts.Host, strconv.Itoa(int(aOpts.Port)))
-	socketConnection, err := dial("tcp", address, aOpts.DialTimeout)
+	c, err := dial(ctx, aOpts.Dialer, "tcp", address, aOpts.DialTimeout)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClientFromConnContext(ctx, c, aOpts)
+}
+
+// NewClientFromConn - Will authenticate against freeswitch over already established connection.
+// Host, Port, DialTimeout and Dialer options are not used, except Host as TLS ServerName.
+// Connection is closed when authentication fails
+func NewClientFromConn(aConn net.Conn, aOpts ConnectOptions) (*Client, error) {
+	return NewClientFromConnContext(context.Background(), aConn, aOpts)
+}
+
+// NewClientFromConnContext - Same as NewClientFromConn but authentication is aborted when ctx is done
+func NewClientFromConnContext(ctx context.Context, aConn net.Conn, aOpts ConnectOptions) (*Client, error) {
+	socketConnection, err := openConnection(ctx, aConn, aOpts.Host, aOpts.TLSConfig, aOpts.Recorder, aOpts.KeepAlive)
 
 	if err != nil {
 		return nil, err
@@ -72,8 +157,10 @@ func NewClient(aOpts ConnectOptions) (*Client, error) {
 	client := &Client{
 		SocketConnection: socketConnection,
 	}
+	client.SetWriteTimeout(aOpts.WriteTimeout)
+	client.setEventBuffer(aOpts.EventBuffer, aOpts.EventOverflow)
 
-	err = client.authenticate(aOpts.Password)
+	err = client.authenticate(ctx, aOpts.User, aOpts.Password)
 	if err != nil {
 		client.Close()
 		return nil, err
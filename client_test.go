@@ -0,0 +1,184 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/PSyton/goesl"
+	"github.com/PSyton/goesl/goesltest"
+)
+
+func TestUserAuth(t *testing.T) {
+	server, err := goesltest.NewServer("ClueCon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	server.AddUser("1000@default", "secret")
+
+	opts := server.ConnectOptions()
+	opts.User = "1000@default"
+	opts.Password = "secret"
+	client, err := goesl.NewClient(opts)
+	if err != nil {
+		t.Fatalf("userauth failed: %v", err)
+	}
+	client.Close()
+
+	opts.Password = "wrong"
+	var rejected *goesl.ErrorAuthRejected
+	_, err = goesl.NewClient(opts)
+	if !errors.As(err, &rejected) || !errors.Is(err, goesl.ErrAuthFailed) {
+		t.Fatalf("expected ErrorAuthRejected, got %v", err)
+	}
+
+	expected := []string{"userauth 1000@default:secret", "userauth 1000@default:wrong"}
+	commands := server.Commands()
+	if len(commands) != len(expected) {
+		t.Fatalf("expected commands %v, got %v", expected, commands)
+	}
+	for i := range expected {
+		if commands[i] != expected[i] {
+			t.Errorf("command %d: expected %q, got %q", i, expected[i], commands[i])
+		}
+	}
+}
+
+func TestRudeRejection(t *testing.T) {
+	server, err := goesltest.NewServer("ClueCon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	server.SetRudeRejection(true)
+
+	var rude *goesl.ErrorRudeRejection
+	_, err = goesl.NewClient(server.ConnectOptions())
+	if !errors.As(err, &rude) || !errors.Is(err, goesl.ErrAuthFailed) {
+		t.Fatalf("expected ErrorRudeRejection, got %v", err)
+	}
+}
+
+func TestDialerAndClientFromConn(t *testing.T) {
+	server, err := goesltest.NewServer("ClueCon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	server.SetAPIResponse("status", "UP")
+
+	ctx, cancel := testContext()
+	defer cancel()
+
+	opts := server.ConnectOptions()
+	opts.Host = "unresolvable.invalid"
+	opts.Dialer = server
+
+	client, err := goesl.NewClient(opts)
+	if err != nil {
+		t.Fatalf("client with dialer: %v", err)
+	}
+	defer client.Close()
+
+	if msg, err := client.Api(ctx, "status"); err != nil || msg.GetReplyText() != "UP" {
+		t.Fatalf("api through dialer: got %v, %v", msg, err)
+	}
+
+	conn, err := server.DialContext(context.Background(), "tcp", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromConn, err := goesl.NewClientFromConn(conn, server.ConnectOptions())
+	if err != nil {
+		t.Fatalf("client from conn: %v", err)
+	}
+	defer fromConn.Close()
+
+	if msg, err := fromConn.Api(ctx, "status"); err != nil || msg.GetReplyText() != "UP" {
+		t.Fatalf("api through conn: got %v, %v", msg, err)
+	}
+	if server.Sessions() != 2 {
+		t.Fatalf("expected 2 sessions, got %d", server.Sessions())
+	}
+}
+
+// blockingDialer never connects, it waits for ctx to finish
+type blockingDialer struct{}
+
+func (blockingDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestDialContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := goesl.DialContext(ctx, goesl.ConnectOptions{Dialer: blockingDialer{}}); err != context.DeadlineExceeded {
+		t.Fatalf("dial: expected context.DeadlineExceeded, got %v", err)
+	}
+
+	// far end accepts connection but never asks for auth
+	dialer := &pipeDialer{far: make(chan net.Conn, 1)}
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	if _, err := goesl.DialContext(ctx, goesl.ConnectOptions{Password: "ClueCon", Dialer: dialer}); err != context.Canceled {
+		t.Fatalf("auth: expected context.Canceled, got %v", err)
+	}
+
+	far := <-dialer.far
+	defer far.Close()
+	if _, err := far.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatalf("expected connection to be closed, got %v", err)
+	}
+}
+
+func TestSendContextCancelledDuringStalledWrite(t *testing.T) {
+	client, far, reader := startPipeClient(t, nil)
+	defer far.Close()
+	defer client.Close()
+
+	// far end doesn't read, so write stalls until ctx is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if err := client.SendContext(ctx, "log 1"); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if client.Poisoned() {
+		t.Fatal("connection must stay usable when nothing was written")
+	}
+
+	// no deadline is left on the connection, so later writes and reads work
+	written := make(chan string, 1)
+	go func() {
+		line, _ := reader.ReadString('\n')
+		written <- line
+	}()
+	if err := client.Send("log 2"); err != nil {
+		t.Fatalf("send after cancelled one: %v", err)
+	}
+	if line := <-written; line != "log 2\r\n" {
+		t.Fatalf("unexpected command %q", line)
+	}
+
+	go io.WriteString(far, "Content-Type: text/event-plain\nContent-Length: 23\n\nEvent-Name: HEARTBEAT\n\n")
+	select {
+	case msg, ok := <-client.Messages():
+		if !ok || msg.GetHeader("Event-Name") != "HEARTBEAT" {
+			t.Fatalf("unexpected message %v, connection error %v", msg, client.Err())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("event wasn't read after cancelled write")
+	}
+}
@@ -9,15 +9,19 @@ package goesl
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net"
 	"net/textproto"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	cryptorand "crypto/rand"
@@ -43,45 +47,214 @@ func getULID() string {
 
 // SocketConnection main connection against ESL
 type SocketConnection struct {
+	// write timeout in nanoseconds, 0 means no timeout. First for 64-bit atomic alignment
+	writeTimeout int64
+	// messages dropped because Messages() buffer was full
+	dropped uint64
+
 	connection net.Conn
 	err        chan error
 	m          chan *Message
+	queue      *messageQueue
 	reader     *bufio.Reader
 	textreader *textproto.Reader
 	mutex      sync.Mutex
 	id         string
+
+	// set when command was written partially, stream is broken after that
+	poisoned int32
+
+	// replies waiting for command/reply or api/response, in send order
+	replyLock sync.Mutex
+	replies   []*pendingReply
+	closed    bool
+
+	// background jobs waiting for BACKGROUND_JOB event
+	jobLock sync.Mutex
+	jobs    map[string]*Job
+
+	// applications waiting for CHANNEL_EXECUTE_COMPLETE, by Event-UUID
+	execLock   sync.Mutex
+	executions map[string]*execution
+
+	// active event subscriptions and filters
+	subLock       sync.Mutex
+	subscriptions Subscriptions
+
+	// closed when connection ends, everything waiting for the connection gives up after that
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+	// closed by Close, buffered messages are not delivered after that
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	// reason connection ended, set once
+	termLock sync.Mutex
+	termErr  error
+
+	// event handlers, reads Messages() once any is registered
+	dispatch dispatcher
+}
+
+// replyResult is a single reply delivered to the command issuer
+type replyResult struct {
+	msg *Message
+	err error
+}
+
+// pendingReply is a slot in the reply queue. Replies for slots without a
+// result channel are delivered to Messages() as before.
+type pendingReply struct {
+	result chan replyResult
 }
 
+// Dialer establishes transport connection to freeswitch. *net.Dialer satisfies it,
+// so do proxy dialers or anything able to return net.Conn (e.g. SSH forwarded connection)
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// DefaultKeepAlivePeriod is TCP keepalive period used when connection options don't specify one
+const DefaultKeepAlivePeriod = time.Second
+
 // create SocketConnection instance
 func newConnection(c net.Conn) *SocketConnection {
 	result := &SocketConnection{
 		connection: c,
-		err:        make(chan error),
+		err:        make(chan error, 1),
 		m:          make(chan *Message),
+		queue:      newMessageQueue(),
+		done:       make(chan struct{}),
+		stop:       make(chan struct{}),
+		jobs:       make(map[string]*Job),
+		executions: make(map[string]*execution),
 		reader:     bufio.NewReaderSize(c, ReadBufferSize),
 		id:         getULID(),
 	}
 	result.textreader = textproto.NewReader(result.reader)
+	return result
+}
+
+// setKeepAlive configures TCP keepalive when c is TCP connection, other transports are left as is.
+// Zero period means DefaultKeepAlivePeriod, negative one disables keepalive
+func setKeepAlive(c net.Conn, period time.Duration) {
+	tcp, ok := underlyingConn(c).(*net.TCPConn)
+	if !ok {
+		return
+	}
 
-	tcp, ok := c.(*net.TCPConn)
-	if ok {
-		if err := tcp.SetKeepAlive(true); err != nil {
-			logger.Error("Can't enable keepalive")
+	if period < 0 {
+		if err := tcp.SetKeepAlive(false); err != nil {
+			logger.Error("Can't disable keepalive")
 		}
-		if err := tcp.SetKeepAlivePeriod(time.Second); err != nil {
-			logger.Error("Can't set keepalive period")
+		return
+	}
+	if period == 0 {
+		period = DefaultKeepAlivePeriod
+	}
+
+	if err := tcp.SetKeepAlive(true); err != nil {
+		logger.Error("Can't enable keepalive")
+	}
+	if err := tcp.SetKeepAlivePeriod(period); err != nil {
+		logger.Error("Can't set keepalive period")
+	}
+}
+
+// underlyingConn unwraps connections wrapped by the library, like recording or TLS connection
+func underlyingConn(c net.Conn) net.Conn {
+	for {
+		if wrapper, ok := c.(interface{ underlying() net.Conn }); ok {
+			c = wrapper.underlying()
+			continue
+		}
+		if tlsConn, ok := c.(interface{ NetConn() net.Conn }); ok {
+			c = tlsConn.NetConn()
+			continue
 		}
+		return c
 	}
-	return result
 }
 
 // Will establish timedout dial against specified address. In this case, it will be freeswitch server
-func dial(network string, addr string, timeout time.Duration) (*SocketConnection, error) {
-	c, err := net.DialTimeout(network, addr, timeout)
-	if err != nil {
+func dial(ctx context.Context, dialer Dialer, network string, addr string, timeout time.Duration) (net.Conn, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+	return dialer.DialContext(ctx, network, addr)
+}
+
+// openConnection prepares established transport for ESL: wraps it with TLS and recorder when set,
+// configures keepalive and completes TLS handshake
+func openConnection(ctx context.Context, c net.Conn, serverName string, tlsConfig *tls.Config, recorder io.Writer, keepAlive time.Duration) (*SocketConnection, error) {
+	setKeepAlive(c, keepAlive)
+
+	if tlsConfig != nil {
+		c = tls.Client(c, clientTLSConfig(tlsConfig, serverName))
+	}
+	if recorder != nil {
+		c = NewRecordingConn(c, recorder)
+	}
+
+	conn := newConnection(c)
+	if err := conn.handshake(ctx); err != nil {
+		conn.Close()
 		return nil, err
 	}
-	return newConnection(c), nil
+	return conn, nil
+}
+
+// watchContext applies earliest of aDeadline and ctx deadline with aSetDeadline and interrupts
+// blocked I/O once ctx is cancelled. Returned function must be called when I/O is finished, it resets the deadline.
+func watchContext(ctx context.Context, aDeadline time.Time, aSetDeadline func(time.Time) error) func() {
+	if deadline, ok := ctx.Deadline(); ok && (aDeadline.IsZero() || deadline.Before(aDeadline)) {
+		aDeadline = deadline
+	}
+
+	if !aDeadline.IsZero() {
+		aSetDeadline(aDeadline)
+	}
+
+	if ctx.Done() == nil {
+		if aDeadline.IsZero() {
+			return func() {}
+		}
+		return func() {
+			aSetDeadline(time.Time{})
+		}
+	}
+
+	finished := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		select {
+		case <-ctx.Done():
+			// deadline in the past unblocks pending I/O
+			aSetDeadline(time.Unix(1, 0))
+		case <-finished:
+		}
+	}()
+
+	return func() {
+		close(finished)
+		<-exited
+		aSetDeadline(time.Time{})
+	}
+}
+
+// contextError prefers ctx error when I/O failed because ctx is done
+func contextError(ctx context.Context, aError error) error {
+	if aError != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return aError
 }
 
 func (c *SocketConnection) writeString(aStr string) error {
@@ -89,22 +262,168 @@ func (c *SocketConnection) writeString(aStr string) error {
 	return err
 }
 
-// Send - Will send raw message to open net connection
-func (c *SocketConnection) Send(cmd string) error {
-	if strings.Contains(cmd, "\r\n") {
-		return newErrorInvalidCommand(cmd)
+// pushReply reserves a slot in the reply queue. Must be called with c.mutex held
+// so the queue order matches the order commands are written.
+func (c *SocketConnection) pushReply(aWait bool) (*pendingReply, error) {
+	c.replyLock.Lock()
+	defer c.replyLock.Unlock()
+
+	if c.closed || c.ended() {
+		return nil, c.closedError()
+	}
+
+	reply := &pendingReply{}
+	c.replies = append(c.replies, reply)
+	if aWait {
+		reply.result = make(chan replyResult, 1)
+		c.wakeReader()
+	}
+	return reply, nil
+}
+
+// dropReply removes slot for the command that was never written
+func (c *SocketConnection) dropReply(aReply *pendingReply) {
+	c.replyLock.Lock()
+	defer c.replyLock.Unlock()
+
+	for i, r := range c.replies {
+		if r == aReply {
+			c.replies = append(c.replies[:i], c.replies[i+1:]...)
+			return
+		}
+	}
+}
+
+// popReply returns the oldest slot or nil when nothing is waiting
+func (c *SocketConnection) popReply() *pendingReply {
+	c.replyLock.Lock()
+	defer c.replyLock.Unlock()
+
+	if len(c.replies) == 0 {
+		return nil
+	}
+
+	reply := c.replies[0]
+	c.replies[0] = nil
+	c.replies = c.replies[1:]
+	return reply
+}
+
+// failReplies releases all waiters once the connection is gone
+func (c *SocketConnection) failReplies() {
+	c.replyLock.Lock()
+	defer c.replyLock.Unlock()
+
+	c.closed = true
+	for _, r := range c.replies {
+		if r.result != nil {
+			r.result <- replyResult{err: c.closedError()}
+		}
+	}
+	c.replies = nil
+}
+
+// sendCommand writes a complete command and reserves a slot for its reply
+func (c *SocketConnection) sendCommand(ctx context.Context, aData []byte, aWait bool) (*pendingReply, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	// lock mutex
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	err := c.writeString(cmd)
+	if c.Poisoned() {
+		return nil, newErrorConnectionPoisoned()
+	}
+
+	reply, err := c.pushReply(aWait)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return c.writeString("\r\n\r\n")
+	var deadline time.Time
+	if timeout := c.WriteTimeout(); timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	stop := watchContext(ctx, deadline, c.connection.SetWriteDeadline)
+	n, err := c.connection.Write(aData)
+	stop()
+
+	if err != nil {
+		c.dropReply(reply)
+
+		if n > 0 {
+			// FreeSWITCH got part of the command, nothing can be sent after that
+			logger.Error(eConnectionPoisoned)
+			atomic.StoreInt32(&c.poisoned, 1)
+			c.Close()
+		} else if c.ended() {
+			return nil, c.closedError()
+		}
+
+		if ctx.Err() == nil && c.isTimeout(err) {
+			return nil, newErrorWriteTiemout(err)
+		}
+		return nil, contextError(ctx, err)
+	}
+
+	return reply, nil
+}
+
+// SetWriteTimeout - Will limit time of every write to the connection. Zero disables timeout
+func (c *SocketConnection) SetWriteTimeout(aTimeout time.Duration) {
+	atomic.StoreInt64(&c.writeTimeout, int64(aTimeout))
+}
+
+// WriteTimeout - Will return current write timeout
+func (c *SocketConnection) WriteTimeout() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.writeTimeout))
+}
+
+// Poisoned - Will return true if a command was written partially and connection can't be used anymore
+func (c *SocketConnection) Poisoned() bool {
+	return atomic.LoadInt32(&c.poisoned) != 0
+}
+
+// waitReply waits for the reply of the command or for context to finish
+func (c *SocketConnection) waitReply(aCtx context.Context, aReply *pendingReply) (*Message, error) {
+	select {
+	case r := <-aReply.result:
+		return r.msg, r.err
+	case <-aCtx.Done():
+		return nil, aCtx.Err()
+	}
+}
+
+// request sends the command and waits for its command/reply or api/response.
+// -ERR reply is returned along with ErrorUnsuccessfulReply
+func (c *SocketConnection) request(aCtx context.Context, aCmd string) (*Message, error) {
+	if strings.ContainsAny(aCmd, "\r\n") {
+		return nil, newErrorInvalidCommand(aCmd)
+	}
+
+	reply, err := c.sendCommand(aCtx, []byte(aCmd+"\r\n\r\n"), true)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.waitReply(aCtx, reply)
+}
+
+// Send - Will send raw message to open net connection
+func (c *SocketConnection) Send(cmd string) error {
+	return c.SendContext(context.Background(), cmd)
+}
+
+// SendContext - Same as Send but gives up writing when ctx is done
+func (c *SocketConnection) SendContext(ctx context.Context, cmd string) error {
+	if strings.ContainsAny(cmd, "\r\n") {
+		return newErrorInvalidCommand(cmd)
+	}
+
+	_, err := c.sendCommand(ctx, []byte(cmd+"\r\n\r\n"), false)
+	return err
 }
 
 // SendMany - Will loop against passed commands and return 1st error if error happens
@@ -125,109 +444,148 @@ func (c *SocketConnection) SendEvent(eventHeaders []string) error {
 		return newErrorSendEvent(len(eventHeaders))
 	}
 
-	// lock mutex to prevent event headers from conflicting
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
-
-	err := c.writeString("sendevent ")
-	if err != nil {
-		return err
-	}
+	b := bytes.NewBufferString("sendevent ")
 
 	for _, eventHeader := range eventHeaders {
-		err := c.writeString(eventHeader)
-		if err != nil {
-			return err
-		}
-
-		err = c.writeString("\r\n")
-		if err != nil {
-			return err
-		}
+		b.WriteString(eventHeader)
+		b.WriteString("\r\n")
 	}
 
-	return c.writeString("\r\n")
+	b.WriteString("\r\n")
+
+	_, err := c.sendCommand(context.Background(), b.Bytes(), false)
+	return err
 }
 
 // Execute - Helper fuck to execute commands with its args and sync/async mode
 func (c *SocketConnection) Execute(command, args string, sync bool) (err error) {
-	return c.SendMsg(map[string]string{
-		"call-command":     "execute",
-		"execute-app-name": command,
-		"execute-app-arg":  args,
-		"event-lock":       strconv.FormatBool(sync),
-	}, "", "")
+	return c.ExecuteContext(context.Background(), command, args, sync)
+}
+
+// ExecuteContext - Same as Execute but gives up writing when ctx is done
+func (c *SocketConnection) ExecuteContext(ctx context.Context, command, args string, sync bool) (err error) {
+	return c.sendMsgNoWait(ctx, NewExecute(command, args).EventLock(sync))
 }
 
 // ExecuteUUID - Helper fuck to execute uuid specific commands with its args and sync/async mode
 func (c *SocketConnection) ExecuteUUID(uuid string, command string, args string, sync bool) (err error) {
-	return c.SendMsg(map[string]string{
-		"call-command":     "execute",
-		"execute-app-name": command,
-		"execute-app-arg":  args,
-		"event-lock":       strconv.FormatBool(sync),
-	}, uuid, "")
+	return c.sendMsgNoWait(context.Background(), NewExecute(command, args).EventLock(sync).UUID(uuid))
 }
 
-// SendMsg - Basically this func will send message to the opened connection
-func (c *SocketConnection) SendMsg(msg map[string]string, uuid, data string) error {
+// sendMsgNoWait sends typed sendmsg, its reply goes to Messages()
+func (c *SocketConnection) sendMsgNoWait(ctx context.Context, msg *SendMsgCommand) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
 
-	b := bytes.NewBufferString("sendmsg")
+	_, err = c.sendCommand(ctx, data, false)
+	return err
+}
 
-	if uuid != "" {
-		if strings.Contains(uuid, "\r\n") {
-			return newErrorInvalidCommand(msg)
-		}
+// SendMsg - Basically this func will send message to the opened connection
+func (c *SocketConnection) SendMsg(msg map[string]string, uuid, data string) error {
+	return c.SendMsgContext(context.Background(), msg, uuid, data)
+}
 
-		b.WriteString(" " + uuid)
+// SendMsgContext - Same as SendMsg but gives up writing when ctx is done
+func (c *SocketConnection) SendMsgContext(ctx context.Context, msg map[string]string, uuid, data string) error {
+	cmd, err := buildSendMsg(msg, uuid, data)
+	if err != nil {
+		return err
 	}
 
-	b.WriteString("\n")
-
-	for k, v := range msg {
-		if strings.Contains(k, "\r\n") {
-			return newErrorInvalidCommand(msg)
-		}
+	_, err = c.sendCommand(ctx, cmd, false)
+	return err
+}
 
-		if v != "" {
-			if strings.Contains(v, "\r\n") {
-				return newErrorInvalidCommand(msg)
-			}
+// buildSendMsg serializes sendmsg command. Headers are sorted, content-length is added for data if missing
+func buildSendMsg(msg map[string]string, uuid, data string) ([]byte, error) {
+	keys := make([]string, 0, len(msg))
+	for k := range msg {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
 
-			b.WriteString(fmt.Sprintf("%s: %s\n", k, v))
+	m := &SendMsgCommand{uuid: uuid}
+	hasLength := false
+	for _, k := range keys {
+		if strings.EqualFold(k, "content-length") {
+			hasLength = true
 		}
+		m.Header(k, msg[k])
 	}
 
-	b.WriteString("\n")
-
-	if msg["content-length"] != "" && data != "" {
-		b.WriteString(data)
+	if data != "" && !hasLength {
+		m.Header("content-length", strconv.Itoa(len(data)))
 	}
 
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
+	b, err := m.Bytes()
+	if err != nil {
+		return nil, newErrorInvalidCommand(msg)
+	}
 
-	_, err := b.WriteTo(c.connection)
-	return err
+	return append(b, data...), nil
 }
 
 // Handle - Will handle new messages and close connection when there are no messages left to process
 func (c *SocketConnection) handle() {
 	logger.Debug("Start handle reads: %s", c.id)
 	defer logger.Debug("Finish handle reads: %s", c.id)
+	go c.pump()
 	for c.readOne() {
 	}
-	// Closing the connection now as there's nothing left to do ...
-	c.Close()
+	c.releaseWaiters()
+	// Closing the connection now as there's nothing left to do, already read messages are still delivered
+	c.shutdown()
+	c.queue.finish()
+}
+
+// shutdown closes net connection and marks connection as ended
+func (c *SocketConnection) shutdown() error {
+	c.closeOnce.Do(func() {
+		c.setErr(newErrorConnectionClosed(nil))
+		c.closeErr = c.connection.Close()
+		close(c.done)
+	})
+
+	return c.closeErr
 }
 
-// Close - Will close down net connection and return error if error happen
+// Close - Will close down net connection and return error if error happen. Messages not taken from Messages()
+// yet are dropped. Safe to call many times, every call returns result of the first one
 func (c *SocketConnection) Close() error {
-	if err := c.connection.Close(); err != nil {
-		return err
-	}
+	err := c.shutdown()
+	c.stopOnce.Do(func() {
+		close(c.stop)
+	})
 
-	return nil
+	return err
+}
+
+// Done - Will return channel that is closed once connection is closed, locally or because reading failed.
+// Messages() is closed after already read messages are delivered, or right away on Close
+func (c *SocketConnection) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err - Will return the reason connection ended: read error, or ErrorConnectionClosed when it was closed locally.
+// Returns nil while connection is alive
+func (c *SocketConnection) Err() error {
+	c.termLock.Lock()
+	defer c.termLock.Unlock()
+
+	return c.termErr
+}
+
+// setErr records the reason connection ended unless it's already known
+func (c *SocketConnection) setErr(aError error) {
+	c.termLock.Lock()
+	defer c.termLock.Unlock()
+
+	if c.termErr == nil {
+		c.termErr = aError
+	}
 }
 
 func (c *SocketConnection) isTimeout(aError error) bool {
@@ -244,14 +602,47 @@ func (c *SocketConnection) isTimeout(aError error) bool {
 
 }
 
+// releaseWaiters fails everything that waits for data from the connection
+func (c *SocketConnection) releaseWaiters() {
+	c.failReplies()
+	c.failJobs()
+	c.failExecutions(c.closedError())
+}
+
+// ended reports whether connection is already closed
+func (c *SocketConnection) ended() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// closedError returns ErrorConnectionClosed wrapping the reason connection ended
+func (c *SocketConnection) closedError() error {
+	return newErrorConnectionClosed(c.Err())
+}
+
+// fatal records and reports error that stops reading, then releases waiters
+func (c *SocketConnection) fatal(aError error) bool {
+	c.setErr(aError)
+	c.releaseWaiters()
+
+	select {
+	case c.err <- aError:
+	default:
+	}
+	return false
+}
+
 func (c *SocketConnection) readOne() bool {
 	hdr, err := c.textreader.ReadMIMEHeader()
 	if err != nil {
 		if c.isTimeout(err) {
 			return true
 		}
-		c.err <- newErrorReadMIMEHeaders(err)
-		return false
+		return c.fatal(newErrorReadMIMEHeaders(err))
 	}
 
 	msg := &Message{}
@@ -260,16 +651,14 @@ func (c *SocketConnection) readOne() bool {
 		length, err := strconv.Atoi(v)
 		if err != nil {
 			logger.Error(eInvalidContentLength, err)
-			c.err <- newErrorInvalidContentLength(err)
-			return false
+			return c.fatal(newErrorInvalidContentLength(err))
 		}
 		msg.Body = make([]byte, length)
 		if _, err := io.ReadFull(c.reader, msg.Body); err != nil {
 			logger.Error(eCouldNotReadBody, err)
 			if err != nil {
 				if !c.isTimeout(err) {
-					c.err <- newErrorCouldNotReadBody(err)
-					return false
+					return c.fatal(newErrorCouldNotReadBody(err))
 				}
 			}
 
@@ -285,21 +674,12 @@ func (c *SocketConnection) readOne() bool {
 	switch contentType {
 	case "command/reply":
 		reply := hdr.Get("Reply-Text")
-		//if reply[:2] == "-E" {
-		//msg.Error = reply[5:]
-		//c.err <- newErrorUnsuccessfulReply(reply[5:])
-		//return true
-		//}
-		if reply[0] == '%' {
+		if reply != "" && reply[0] == '%' {
 			copyHeaders(&hdr, msg, true)
 		} else {
 			copyHeaders(&hdr, msg, false)
 		}
 	case "api/response":
-		//if string(msg.Body[:2]) == "-E" {
-		///c.err <- errors.New(string(msg.Body)[5:])
-		//return true
-		//}
 		copyHeaders(&hdr, msg, false)
 	case "text/event-plain":
 		reader := bufio.NewReader(bytes.NewReader(msg.Body))
@@ -307,21 +687,18 @@ func (c *SocketConnection) readOne() bool {
 		textreader := textproto.NewReader(reader)
 		hdr, err = textreader.ReadMIMEHeader()
 		if err != nil {
-			c.err <- err
-			return false
+			return c.fatal(err)
 		}
 		if v := hdr.Get("Content-Length"); v != "" {
 			length, err := strconv.Atoi(v)
 			if err != nil {
 				logger.Error(eInvalidContentLength, err)
-				c.err <- newErrorInvalidContentLength(err)
-				return false
+				return c.fatal(newErrorInvalidContentLength(err))
 			}
 			msg.Body = make([]byte, length)
 			if _, err = io.ReadFull(reader, msg.Body); err != nil {
 				logger.Error(eCouldNotReadBody, err)
-				c.err <- newErrorCouldNotReadBody(err)
-				return false
+				return c.fatal(newErrorCouldNotReadBody(err))
 			}
 		}
 		copyHeaders(&hdr, msg, true)
@@ -329,8 +706,7 @@ func (c *SocketConnection) readOne() bool {
 		decoded := make(map[string]interface{})
 		if err := json.Unmarshal(msg.Body, &decoded); err != nil {
 			logger.Error(eUnmarshallJSON, err)
-			c.err <- newErrorUnmarshallJSON(err)
-			return false
+			return c.fatal(newErrorUnmarshallJSON(err))
 		}
 
 		// Copy back in:
@@ -351,14 +727,33 @@ func (c *SocketConnection) readOne() bool {
 		} else {
 			msg.Body = []byte("")
 		}
-	case "text/disconnect-notice":
+	case "text/event-xml":
+		xmlHdr, body, err := parseXMLEvent(msg.Body)
+		if err != nil {
+			logger.Error(eUnmarshallXML, err)
+			return c.fatal(newErrorUnmarshallXML(err))
+		}
+		msg.Body = body
+		copyHeaders(&xmlHdr, msg, true)
+	case "text/disconnect-notice", "text/rude-rejection":
 		copyHeaders(&hdr, msg, false)
 	default:
 		return true
 	}
 
-	c.m <- msg
-	return true
+	if contentType == "command/reply" || contentType == "api/response" {
+		// replies come back in the order commands were sent, -ERR goes to the issuer as error
+		if reply := c.popReply(); reply != nil && reply.result != nil {
+			reply.result <- replyResult{msg: msg, err: msg.replyError()}
+			return true
+		}
+	}
+
+	if c.resolveJob(msg) || c.resolveExecution(msg) {
+		return true
+	}
+
+	return c.deliver(msg)
 }
 
 // copyHeaders copies all keys and values from the MIMEHeader to Event.Header,
@@ -408,12 +803,13 @@ func capitalize(s string) string {
 	return string(ns)
 }
 
-// Errors - returns error channel
+// Errors - returns error channel. It receives the error that stopped reading once, see also Err
 func (c *SocketConnection) Errors() chan error {
 	return c.err
 }
 
-// Messages - returns messages channel
+// Messages - returns messages channel. It's closed when connection ends and read messages are taken.
+// Close must be called to release connection whose messages are not read
 func (c *SocketConnection) Messages() chan *Message {
 	return c.m
 }
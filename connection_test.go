@@ -0,0 +1,429 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PSyton/goesl"
+	"github.com/PSyton/goesl/goesltest"
+)
+
+// startClient starts fake FreeSWITCH and client connected to it. Both must be closed by caller
+func startClient(t *testing.T, configure func(opts *goesl.ConnectOptions)) (*goesltest.Server, *goesl.Client) {
+	t.Helper()
+
+	server, err := goesltest.NewServer("ClueCon")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := server.ConnectOptions()
+	if configure != nil {
+		configure(&opts)
+	}
+
+	client, err := goesl.NewClient(opts)
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	return server, client
+}
+
+func testContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), 5*time.Second)
+}
+
+func TestApiRepliesMatchCommandsMixedWithSendAndEvents(t *testing.T) {
+	server, client := startClient(t, nil)
+	defer server.Close()
+	defer client.Close()
+
+	server.HandleAPI(func(command string) string {
+		return "reply to " + command
+	})
+	server.HandleCommand(func(command string, headers map[string]string) string {
+		return "+OK " + command
+	})
+
+	ctx, cancel := testContext()
+	defer cancel()
+
+	if err := client.Subscribe(ctx, goesl.EventFormatPlain, "ALL"); err != nil {
+		t.Fatal(err)
+	}
+
+	const count = 20
+
+	var lock sync.Mutex
+	replies := make(map[string]bool)
+	events := 0
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for msg := range client.Messages() {
+			lock.Lock()
+			switch msg.GetHeader("Content-Type") {
+			case "command/reply":
+				replies[msg.GetReplyText()] = true
+			case "api/response":
+				t.Errorf("api/response leaked to Messages(): %s", msg)
+			default:
+				if msg.GetHeader("Event-Name") == string(goesl.EventHeartbeat) {
+					events++
+				}
+			}
+			lock.Unlock()
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(3)
+		go func(i int) {
+			defer wg.Done()
+			command := fmt.Sprintf("status %d", i)
+			msg, err := client.Api(ctx, command)
+			if err != nil {
+				t.Errorf("%s: %v", command, err)
+				return
+			}
+			if got := msg.GetReplyText(); got != "reply to "+command {
+				t.Errorf("%s: got reply %q", command, got)
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			if err := client.Send(fmt.Sprintf("log %d", i)); err != nil {
+				t.Error(err)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			if err := server.PushEvent(goesl.EventFormatPlain, map[string]string{"Event-Name": "HEARTBEAT"}, ""); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		lock.Lock()
+		done := len(replies) == count && events == count
+		lock.Unlock()
+		if done {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d replies and events, got %d and %d", count, len(replies), events)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for i := 0; i < count; i++ {
+		if !replies[fmt.Sprintf("+OK log %d", i)] {
+			t.Fatalf("missing reply for log %d", i)
+		}
+	}
+
+	client.Close()
+	<-collected
+}
+
+func TestMultilineCommandsAreRejected(t *testing.T) {
+	server, client := startClient(t, nil)
+	defer server.Close()
+	defer client.Close()
+	server.SetAPIResponse("status", "UP")
+
+	ctx, cancel := testContext()
+	defer cancel()
+
+	var invalid *goesl.ErrorInvalidCommand
+	for _, command := range []string{"status\r\n\r\napi evil", "status\n\napi evil", "status\rapi evil"} {
+		if err := client.Send("api " + command); !errors.As(err, &invalid) {
+			t.Errorf("send %q: expected ErrorInvalidCommand, got %v", command, err)
+		}
+		if _, err := client.Api(ctx, command); !errors.As(err, &invalid) {
+			t.Errorf("api %q: expected ErrorInvalidCommand, got %v", command, err)
+		}
+		if _, err := client.BgApi(ctx, command); !errors.As(err, &invalid) {
+			t.Errorf("bgapi %q: expected ErrorInvalidCommand, got %v", command, err)
+		}
+	}
+
+	// nothing was written, so replies still match their commands
+	if msg, err := client.Api(ctx, "status"); err != nil || msg.GetReplyText() != "UP" {
+		t.Fatalf("got %v, %v", msg, err)
+	}
+	for _, command := range server.Commands() {
+		if strings.Contains(command, "evil") {
+			t.Fatalf("server received %q", command)
+		}
+	}
+
+	opts := server.ConnectOptions()
+	opts.User = "1000@default"
+	opts.Password = "secret\n\napi evil"
+	if _, err := goesl.NewClient(opts); !errors.As(err, &invalid) {
+		t.Fatalf("userauth: expected ErrorInvalidCommand, got %v", err)
+	}
+}
+
+func TestErrRepliesGoToIssuingCaller(t *testing.T) {
+	server, client := startClient(t, nil)
+	defer server.Close()
+	defer client.Close()
+
+	server.HandleCommand(func(command string, headers map[string]string) string {
+		if strings.HasPrefix(command, "filter") || strings.HasPrefix(command, "log") {
+			return "-ERR rejected " + command
+		}
+		return "+OK " + command
+	})
+	server.SetAPIResponse("status", "UP")
+
+	ctx, cancel := testContext()
+	defer cancel()
+
+	var unsuccessful *goesl.ErrorUnsuccessfulReply
+	_, err := client.Api(ctx, "bogus")
+	if !errors.As(err, &unsuccessful) || unsuccessful.Reason != "bogus Command not found!" {
+		t.Fatalf("expected unsuccessful reply for unknown api, got %v", err)
+	}
+	if !errors.Is(err, goesl.ErrCommandRejected) {
+		t.Fatalf("expected ErrCommandRejected, got %v", err)
+	}
+
+	err = client.AddFilter(ctx, "Unique-ID", "uuid-1")
+	if !errors.As(err, &unsuccessful) || unsuccessful.Reason != "rejected filter Unique-ID uuid-1" {
+		t.Fatalf("expected unsuccessful reply for filter, got %v", err)
+	}
+
+	if msg, err := client.Api(ctx, "status"); err != nil || msg.GetReplyText() != "UP" {
+		t.Fatalf("api after rejected commands: got %v, %v", msg, err)
+	}
+
+	// replies nobody waits for are delivered to Messages() as they are
+	if err := client.Send("log 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-client.Messages():
+		if msg.GetHeader("Content-Type") != "command/reply" || msg.IsSuccessful() || msg.GetReplyText() != "-ERR rejected log 1" {
+			t.Fatalf("expected unsuccessful reply to Send, got %s", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("reply to Send wasn't delivered")
+	}
+	if client.Err() != nil {
+		t.Fatalf("connection failed: %v", client.Err())
+	}
+}
+
+// waitClosed fails unless messages channel is closed in time, discarding messages left in it
+func waitClosed(t *testing.T, messages chan *goesl.Message) {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-messages:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("messages channel wasn't closed")
+		}
+	}
+}
+
+func TestCloseWithoutConsumerEndsConnection(t *testing.T) {
+	server, client := startClient(t, nil)
+	defer server.Close()
+
+	// nobody reads Messages(), so reading gets stuck on the full buffer
+	for i := 0; i < 5; i++ {
+		if err := server.PushEvent(goesl.EventFormatPlain, map[string]string{"Event-Name": "HEARTBEAT"}, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		client.Close()
+		client.Close()
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close blocked")
+	}
+
+	select {
+	case <-client.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Done wasn't closed")
+	}
+	waitClosed(t, client.Messages())
+
+	if !errors.Is(client.Err(), goesl.ErrConnectionClosed) {
+		t.Fatalf("expected ErrConnectionClosed, got %v", client.Err())
+	}
+}
+
+func TestMessagesCloseAfterRemoteDisconnect(t *testing.T) {
+	server, client := startClient(t, nil)
+	defer server.Close()
+	defer client.Close()
+
+	if err := server.PushEvent(goesl.EventFormatPlain, map[string]string{"Event-Name": "HEARTBEAT"}, ""); err != nil {
+		t.Fatal(err)
+	}
+	server.Disconnect()
+
+	var received []*goesl.Message
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		for msg := range client.Messages() {
+			received = append(received, msg)
+		}
+	}()
+
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("messages channel wasn't closed")
+	}
+
+	if len(received) != 2 {
+		t.Fatalf("expected event and disconnect notice, got %d messages", len(received))
+	}
+	if name := received[0].GetHeader("Event-Name"); name != "HEARTBEAT" {
+		t.Errorf("expected HEARTBEAT first, got %q", name)
+	}
+	if ct := received[1].GetHeader("Content-Type"); ct != "text/disconnect-notice" {
+		t.Errorf("expected disconnect notice last, got %q", ct)
+	}
+
+	<-client.Done()
+	if !errors.Is(client.Err(), io.EOF) {
+		t.Fatalf("expected io.EOF, got %v", client.Err())
+	}
+}
+
+// pipeDialer connects client to in-memory far end the test drives by hand
+type pipeDialer struct {
+	far chan net.Conn
+}
+
+func (d *pipeDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	near, far := net.Pipe()
+	d.far <- far
+	return near, nil
+}
+
+// startPipeClient connects client to the far end of pipe, authenticates it and returns far end reader.
+// Far end reads nothing afterwards unless the test does
+func startPipeClient(t *testing.T, configure func(opts *goesl.ConnectOptions)) (*goesl.Client, net.Conn, *bufio.Reader) {
+	t.Helper()
+
+	dialer := &pipeDialer{far: make(chan net.Conn, 1)}
+	opts := goesl.ConnectOptions{Password: "ClueCon", Dialer: dialer}
+	if configure != nil {
+		configure(&opts)
+	}
+
+	type result struct {
+		client *goesl.Client
+		err    error
+	}
+	connected := make(chan result, 1)
+	go func() {
+		client, err := goesl.NewClient(opts)
+		connected <- result{client, err}
+	}()
+
+	far := <-dialer.far
+	reader := bufio.NewReader(far)
+	io.WriteString(far, "Content-Type: auth/request\n\n")
+	if line, err := reader.ReadString('\n'); err != nil || line != "auth ClueCon\r\n" {
+		t.Fatalf("expected auth, got %q, %v", line, err)
+	}
+	reader.ReadString('\n')
+	io.WriteString(far, "Content-Type: command/reply\nReply-Text: +OK accepted\n\n")
+
+	r := <-connected
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+	return r.client, far, reader
+}
+
+func TestWriteTimeout(t *testing.T) {
+	client, far, _ := startPipeClient(t, func(opts *goesl.ConnectOptions) {
+		opts.WriteTimeout = 50 * time.Millisecond
+	})
+	defer far.Close()
+	defer client.Close()
+
+	// far end doesn't read, so nothing gets written
+	err := client.Send("log 1")
+	var timeout *goesl.ErrorWriteTiemout
+	if !errors.As(err, &timeout) || !errors.Is(err, goesl.ErrTimeout) {
+		t.Fatalf("expected write timeout, got %v", err)
+	}
+	if client.Poisoned() {
+		t.Fatal("connection must stay usable when nothing was written")
+	}
+}
+
+func TestPartialWritePoisonsConnection(t *testing.T) {
+	client, far, _ := startPipeClient(t, func(opts *goesl.ConnectOptions) {
+		opts.WriteTimeout = 50 * time.Millisecond
+	})
+	defer far.Close()
+	defer client.Close()
+
+	// far end takes the beginning of the command only
+	go far.Read(make([]byte, 3))
+
+	if err := client.Send("log 1"); !errors.Is(err, goesl.ErrTimeout) {
+		t.Fatalf("expected write timeout, got %v", err)
+	}
+	if !client.Poisoned() {
+		t.Fatal("expected connection to be poisoned")
+	}
+
+	err := client.Send("log 2")
+	var poisoned *goesl.ErrorConnectionPoisoned
+	if !errors.As(err, &poisoned) || !errors.Is(err, goesl.ErrConnectionClosed) {
+		t.Fatalf("expected ErrorConnectionPoisoned, got %v", err)
+	}
+
+	select {
+	case <-client.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("poisoned connection wasn't closed")
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl
+
+import (
+	"sync"
+)
+
+// EventHandler - Called by dispatcher for every event handler is registered for
+type EventHandler func(ev *Event)
+
+// EventMatcher - Reports whether handler registered with OnEventMatch wants the event
+type EventMatcher func(ev *Event) bool
+
+// MessageHandler - Called by dispatcher for messages no event handler matched
+type MessageHandler func(msg *Message)
+
+// Registration - Handle of handler registered in connection dispatcher
+type Registration struct {
+	d  *dispatcher
+	id uint64
+}
+
+// Unregister - Will stop calling the handler. Safe to call many times
+func (r *Registration) Unregister() {
+	r.d.remove(r.id)
+}
+
+type dispatchEntry struct {
+	id      uint64
+	match   EventMatcher
+	handler EventHandler
+}
+
+// dispatcher delivers messages from Messages() to registered handlers
+type dispatcher struct {
+	once     sync.Once
+	lock     sync.Mutex
+	nextID   uint64
+	entries  []dispatchEntry
+	fallback MessageHandler
+	// id of registration that set fallback
+	fallbackID uint64
+}
+
+func (d *dispatcher) add(aMatch EventMatcher, aHandler EventHandler) *Registration {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.nextID++
+	d.entries = append(d.entries, dispatchEntry{id: d.nextID, match: aMatch, handler: aHandler})
+	return &Registration{d: d, id: d.nextID}
+}
+
+func (d *dispatcher) setFallback(aHandler MessageHandler) *Registration {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.nextID++
+	d.fallback = aHandler
+	d.fallbackID = d.nextID
+	return &Registration{d: d, id: d.nextID}
+}
+
+func (d *dispatcher) remove(aID uint64) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.fallbackID == aID {
+		d.fallback = nil
+		d.fallbackID = 0
+		return
+	}
+
+	for i, e := range d.entries {
+		if e.id == aID {
+			// copy so dispatch in progress keeps its snapshot intact
+			entries := make([]dispatchEntry, 0, len(d.entries)-1)
+			entries = append(entries, d.entries[:i]...)
+			d.entries = append(entries, d.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// run dispatches messages until the channel is closed
+func (d *dispatcher) run(aMessages <-chan *Message) {
+	for msg := range aMessages {
+		d.dispatch(msg)
+	}
+}
+
+// dispatch calls every matching handler in registration order, or fallback if none matched
+func (d *dispatcher) dispatch(aMsg *Message) {
+	d.lock.Lock()
+	entries := d.entries
+	fallback := d.fallback
+	d.lock.Unlock()
+
+	matched := false
+	if ev, err := aMsg.AsEvent(); err == nil {
+		for _, e := range entries {
+			if e.match(ev) {
+				matched = true
+				e.handler(ev)
+			}
+		}
+	}
+
+	if !matched && fallback != nil {
+		fallback(aMsg)
+	}
+}
+
+// register adds handler and starts dispatching. From that moment dispatcher is the only reader of Messages()
+func (c *SocketConnection) register(aMatch EventMatcher, aHandler EventHandler) *Registration {
+	r := c.dispatch.add(aMatch, aHandler)
+	c.startDispatch()
+	return r
+}
+
+func (c *SocketConnection) startDispatch() {
+	c.dispatch.once.Do(func() {
+		go c.dispatch.run(c.Messages())
+	})
+}
+
+// OnEvent - Will call handler for events with the name. Handlers are called one by one in registration order
+// from dispatcher goroutine reading Messages(), so slow handler delays following events and, with OverflowBlock,
+// reading of the connection. Handler may wait for replies (Api, ExecuteAndWait...), reading doesn't stop for
+// buffered events meanwhile.
+// Once any handler is registered, messages are consumed by dispatcher and don't come from Messages() anymore
+func (c *SocketConnection) OnEvent(name EventName, handler EventHandler) *Registration {
+	return c.register(func(ev *Event) bool {
+		return ev.Name() == name
+	}, handler)
+}
+
+// OnCustom - Will call handler for CUSTOM events with the subclass, like sofia::register
+func (c *SocketConnection) OnCustom(subclass string, handler EventHandler) *Registration {
+	return c.register(func(ev *Event) bool {
+		return ev.Name() == EventCustom && ev.Subclass() == subclass
+	}, handler)
+}
+
+// OnChannel - Will call handler for events of the channel with the uuid
+func (c *SocketConnection) OnChannel(uuid string, handler EventHandler) *Registration {
+	return c.register(func(ev *Event) bool {
+		return ev.UniqueID() == uuid
+	}, handler)
+}
+
+// OnEventMatch - Will call handler for events the matcher accepts
+func (c *SocketConnection) OnEventMatch(match EventMatcher, handler EventHandler) *Registration {
+	return c.register(match, handler)
+}
+
+// OnAnyEvent - Will call handler for every event
+func (c *SocketConnection) OnAnyEvent(handler EventHandler) *Registration {
+	return c.register(func(ev *Event) bool {
+		return true
+	}, handler)
+}
+
+// OnUnmatched - Will call handler for messages no event handler matched, including non event messages
+// like text/disconnect-notice. Replaces previously set one. Unmatched messages are dropped when it's not set
+func (c *SocketConnection) OnUnmatched(handler MessageHandler) *Registration {
+	r := c.dispatch.setFallback(handler)
+	c.startDispatch()
+	return r
+}
@@ -0,0 +1,90 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/PSyton/goesl"
+)
+
+func TestDispatcherRoutesEvents(t *testing.T) {
+	server, client := startClient(t, nil)
+	defer server.Close()
+	defer client.Close()
+	server.SetAPIResponse("status", "UP")
+
+	calls := make(chan string, 16)
+	client.OnEvent(goesl.EventChannelAnswer, func(ev *goesl.Event) {
+		calls <- "answer " + ev.UniqueID()
+	})
+	client.OnCustom("sofia::register", func(ev *goesl.Event) {
+		calls <- "register"
+	})
+	channel := client.OnChannel("uuid-1", func(ev *goesl.Event) {
+		calls <- "channel " + string(ev.Name())
+	})
+	client.OnEvent(goesl.EventHeartbeat, func(ev *goesl.Event) {
+		// handlers may wait for replies, reading doesn't wait for them
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		msg, err := client.Api(ctx, "status")
+		if err != nil {
+			calls <- err.Error()
+			return
+		}
+		calls <- "heartbeat " + msg.GetReplyText()
+	})
+	client.OnUnmatched(func(msg *goesl.Message) {
+		if name := msg.GetHeader("Event-Name"); name != "" {
+			calls <- "unmatched " + name
+			return
+		}
+		calls <- "unmatched " + msg.GetHeader("Content-Type")
+	})
+
+	push := func(headers map[string]string) {
+		t.Helper()
+		if err := server.PushEvent(goesl.EventFormatPlain, headers, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expect := func(expected ...string) {
+		t.Helper()
+		for _, want := range expected {
+			select {
+			case got := <-calls:
+				if got != want {
+					t.Fatalf("expected %q, got %q", want, got)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("expected %q, got nothing", want)
+			}
+		}
+	}
+
+	push(map[string]string{"Event-Name": "CHANNEL_ANSWER", "Unique-ID": "uuid-1"})
+	expect("answer uuid-1", "channel CHANNEL_ANSWER")
+
+	push(map[string]string{"Event-Name": "CUSTOM", "Event-Subclass": "sofia::register"})
+	expect("register")
+
+	push(map[string]string{"Event-Name": "HEARTBEAT"})
+	expect("heartbeat UP")
+
+	channel.Unregister()
+	channel.Unregister()
+	push(map[string]string{"Event-Name": "CHANNEL_HANGUP", "Unique-ID": "uuid-1"})
+	push(map[string]string{"Event-Name": "CHANNEL_ANSWER", "Unique-ID": "uuid-2"})
+	expect("unmatched CHANNEL_HANGUP", "answer uuid-2")
+
+	server.Disconnect()
+	expect("unmatched text/disconnect-notice")
+}
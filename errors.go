@@ -7,7 +7,21 @@
 package goesl
 
 import (
+	"errors"
 	"fmt"
+	"io"
+)
+
+// Sentinel errors to check with errors.Is. Every typed error below matches at most one of them
+var (
+	// ErrAuthFailed - FreeSWITCH refused authentication or the connection itself
+	ErrAuthFailed = errors.New("goesl: authentication failed")
+	// ErrConnectionClosed - Connection is closed or broken and can't be used anymore
+	ErrConnectionClosed = errors.New("goesl: connection closed")
+	// ErrTimeout - Write didn't complete within connection write timeout
+	ErrTimeout = errors.New("goesl: timeout")
+	// ErrCommandRejected - FreeSWITCH answered command with -ERR
+	ErrCommandRejected = errors.New("goesl: command rejected")
 )
 
 const (
@@ -19,38 +33,92 @@ const (
 	eUnsupportedMessageType     = "Unsupported message type! We got '%s'. Supported types are: %v "
 	eUnsupportedMessageTypeLite = "Unsupported message type! We got '%s'"
 	eUnmarshallJSON             = "Error while unmarshal JSON event: %s"
+	eUnmarshallXML              = "Error while unmarshal XML event: %s"
 	eCouldNotStartListener      = "Got error while attempting to start listener: %s"
 	eListenerConnection         = "Listener connection error: %s"
+	eTLSHandshake               = "TLS handshake failed: %s"
 	invalidServerAddr           = "Please make sure to pass along valid address. You've passed: \"%s\""
 	unexpectedAuthHeader        = "Expected auth/request content type. Got %s"
 	invalidPassword             = "Could not authenticate against freeswitch with provided password."
 	wRemoveNonStringProperty    = "Removed non-string property (%s)"
 	errorWhileAccepConnection   = "Got error while accepting connection: %s"
 	errorWriteTimeout           = "Wrtie timeout"
+	eConnectionClosed           = "Connection closed"
+	eConnectionPoisoned         = "Connection is broken by partially written command"
+	eJobCancelled               = "Background job %s cancelled"
+	eNotEvent                   = "Message is not an event. Content type: '%s'"
+	eChannelHangup              = "Channel %s hung up: %s"
+	eApplicationFailed          = "Application %s failed: %s"
+	eAuthRejected               = "Authentication rejected: %s"
+	eRudeRejection              = "Connection rejected by FreeSWITCH ACL: %s"
+	eEventOverflow              = "Messages buffer of %d is full, disconnecting"
 )
 
 type errorImpl struct {
 	message string
+	// underlying error returned by Unwrap
+	cause error
+	// sentinel error matched by Is
+	kind error
 }
 
 func (e *errorImpl) Error() string {
 	return e.message
 }
 
+// Unwrap - Will return underlying error, if any
+func (e *errorImpl) Unwrap() error {
+	return e.cause
+}
+
+// Is - Will report whether error belongs to the sentinel target
+func (e *errorImpl) Is(aTarget error) bool {
+	return e.kind != nil && e.kind == aTarget
+}
+
 func newError(aMsg string) errorImpl {
 	return errorImpl{
 		message: aMsg,
 	}
 }
 
+func (e errorImpl) withCause(aCause error) errorImpl {
+	e.cause = aCause
+	return e
+}
+
+func (e errorImpl) withKind(aKind error) errorImpl {
+	e.kind = aKind
+	return e
+}
+
+// readErrorKind reports ErrConnectionClosed when read failed because stream ended
+func readErrorKind(aError error) error {
+	if errors.Is(aError, io.EOF) || errors.Is(aError, io.ErrUnexpectedEOF) {
+		return ErrConnectionClosed
+	}
+	return nil
+}
+
 // ErrorWriteTiemout timeout error
 type ErrorWriteTiemout struct {
 	errorImpl
 }
 
-func newErrorWriteTiemout() *ErrorWriteTiemout {
+func newErrorWriteTiemout(aError error) *ErrorWriteTiemout {
 	return &ErrorWriteTiemout{
-		errorImpl: newError(errorWriteTimeout),
+		errorImpl: newError(errorWriteTimeout).withCause(aError).withKind(ErrTimeout),
+	}
+}
+
+// ErrorConnectionPoisoned fired when sending to connection broken by partially written command
+type ErrorConnectionPoisoned struct {
+	errorImpl
+}
+
+func newErrorConnectionPoisoned() *ErrorConnectionPoisoned {
+	return &ErrorConnectionPoisoned{
+		errorImpl: newError(eConnectionPoisoned).withKind(ErrConnectionClosed),
 	}
 }
 
@@ -61,7 +129,7 @@ type ErrorInvalidCommand struct {
 
 func newErrorInvalidCommand(aData interface{}) *ErrorInvalidCommand {
 	return &ErrorInvalidCommand{
-		errorImpl: newError(fmt.Sprint(eInvalidCommand, aData)),
+		errorImpl: newError(fmt.Sprintf(eInvalidCommand, aData)),
 	}
 }
 
@@ -72,7 +140,7 @@ type ErrorReadMIMEHeaders struct {
 
 func newErrorReadMIMEHeaders(aError error) *ErrorReadMIMEHeaders {
 	return &ErrorReadMIMEHeaders{
-		errorImpl: newError(fmt.Sprintf(eCouldNotReadMIMEHeaders, aError.Error())),
+		errorImpl: newError(fmt.Sprintf(eCouldNotReadMIMEHeaders, aError.Error())).withCause(aError).withKind(readErrorKind(aError)),
 	}
 }
 
@@ -83,18 +151,20 @@ type ErrorInvalidContentLength struct {
 
 func newErrorInvalidContentLength(aError error) *ErrorInvalidContentLength {
 	return &ErrorInvalidContentLength{
-		errorImpl: newError(fmt.Sprintf(eInvalidContentLength, aError.Error())),
+		errorImpl: newError(fmt.Sprintf(eInvalidContentLength, aError.Error())).withCause(aError),
 	}
 }
 
-// ErrorUnsuccessfulReply ...
+// ErrorUnsuccessfulReply - Command was answered with -ERR. Reason is reply text following -ERR
 type ErrorUnsuccessfulReply struct {
 	errorImpl
+	Reason string
 }
 
-func newErrorUnsuccessfulReply(aReply string) *ErrorUnsuccessfulReply {
+func newErrorUnsuccessfulReply(aReason string) *ErrorUnsuccessfulReply {
 	return &ErrorUnsuccessfulReply{
-		errorImpl: newError(fmt.Sprintf(eUnsuccessfulReply, aReply)),
+		errorImpl: newError(fmt.Sprintf(eUnsuccessfulReply, aReason)).withKind(ErrCommandRejected),
+		Reason:    aReason,
 	}
 }
 
@@ -105,7 +175,7 @@ type ErrorCouldNotReadBody struct {
 
 func newErrorCouldNotReadBody(aError error) *ErrorCouldNotReadBody {
 	return &ErrorCouldNotReadBody{
-		errorImpl: newError(fmt.Sprintf(eCouldNotReadBody, aError.Error())),
+		errorImpl: newError(fmt.Sprintf(eCouldNotReadBody, aError.Error())).withCause(aError).withKind(readErrorKind(aError)),
 	}
 }
 
@@ -114,8 +184,8 @@ type ErrorUnsupportedMessageType struct {
 	errorImpl
 }
 
-func newErrorUnsupportedMessageType(aType string) *ErrorUnsuccessfulReply {
-	return &ErrorUnsuccessfulReply{
+func newErrorUnsupportedMessageType(aType string) *ErrorUnsupportedMessageType {
+	return &ErrorUnsupportedMessageType{
 		errorImpl: newError(fmt.Sprintf(eUnsupportedMessageTypeLite, aType)),
 	}
 }
@@ -149,7 +219,33 @@ type ErrorInvalidPassword struct {
 
 func newErrorInvalidPassword() *ErrorInvalidPassword {
 	return &ErrorInvalidPassword{
-		errorImpl: newError(invalidPassword),
+		errorImpl: newError(invalidPassword).withKind(ErrAuthFailed),
+	}
+}
+
+// ErrorAuthRejected fired when FreeSWITCH replies -ERR to auth or userauth
+type ErrorAuthRejected struct {
+	errorImpl
+	Reason string
+}
+
+func newErrorAuthRejected(aReason string) *ErrorAuthRejected {
+	return &ErrorAuthRejected{
+		errorImpl: newError(fmt.Sprintf(eAuthRejected, aReason)).withKind(ErrAuthFailed),
+		Reason:    aReason,
+	}
+}
+
+// ErrorRudeRejection fired when FreeSWITCH denies connection with text/rude-rejection
+type ErrorRudeRejection struct {
+	errorImpl
+	Reason string
+}
+
+func newErrorRudeRejection(aReason string) *ErrorRudeRejection {
+	return &ErrorRudeRejection{
+		errorImpl: newError(fmt.Sprintf(eRudeRejection, aReason)).withKind(ErrAuthFailed),
+		Reason:    aReason,
 	}
 }
 
@@ -160,7 +256,18 @@ type ErrorUnmarshallJSON struct {
 
 func newErrorUnmarshallJSON(aError error) *ErrorUnmarshallJSON {
 	return &ErrorUnmarshallJSON{
-		errorImpl: newError(fmt.Sprintf(eUnmarshallJSON, aError.Error())),
+		errorImpl: newError(fmt.Sprintf(eUnmarshallJSON, aError.Error())).withCause(aError),
+	}
+}
+
+// ErrorUnmarshallXML ...
+type ErrorUnmarshallXML struct {
+	errorImpl
+}
+
+func newErrorUnmarshallXML(aError error) *ErrorUnmarshallXML {
+	return &ErrorUnmarshallXML{
+		errorImpl: newError(fmt.Sprintf(eUnmarshallXML, aError.Error())).withCause(aError),
 	}
 }
 
@@ -174,3 +281,89 @@ func newErrorSendEvent(aLen int) *ErrorSendEvent {
 		errorImpl: newError(fmt.Sprintf("Must send at least one event header, detected `%d` header", aLen)),
 	}
 }
+
+// ErrorConnectionClosed fired when command can't get reply because connection is closed.
+// Wraps the reason connection ended, if it was not closed locally
+type ErrorConnectionClosed struct {
+	errorImpl
+}
+
+func newErrorConnectionClosed(aCause error) *ErrorConnectionClosed {
+	var closed *ErrorConnectionClosed
+	if errors.As(aCause, &closed) {
+		return closed
+	}
+
+	message := eConnectionClosed
+	if aCause != nil {
+		message += ": " + aCause.Error()
+	}
+	return &ErrorConnectionClosed{
+		errorImpl: newError(message).withCause(aCause).withKind(ErrConnectionClosed),
+	}
+}
+
+// ErrorJobCancelled fired when background job stopped waiting for its result
+type ErrorJobCancelled struct {
+	errorImpl
+}
+
+func newErrorJobCancelled(aUUID string) *ErrorJobCancelled {
+	return &ErrorJobCancelled{
+		errorImpl: newError(fmt.Sprintf(eJobCancelled, aUUID)),
+	}
+}
+
+// ErrorNotEvent fired when message without Event-Name is converted to Event
+type ErrorNotEvent struct {
+	errorImpl
+}
+
+func newErrorNotEvent(aCType string) *ErrorNotEvent {
+	return &ErrorNotEvent{
+		errorImpl: newError(fmt.Sprintf(eNotEvent, aCType)),
+	}
+}
+
+// ErrorChannelHangup fired when channel hangs up before application completes
+type ErrorChannelHangup struct {
+	errorImpl
+	UUID  string
+	Cause string
+}
+
+func newErrorChannelHangup(aUUID, aCause string) *ErrorChannelHangup {
+	return &ErrorChannelHangup{
+		errorImpl: newError(fmt.Sprintf(eChannelHangup, aUUID, aCause)),
+		UUID:      aUUID,
+		Cause:     aCause,
+	}
+}
+
+// ErrorApplicationFailed fired when executed application reports failure
+type ErrorApplicationFailed struct {
+	errorImpl
+	App      string
+	Response string
+}
+
+func newErrorApplicationFailed(aApp, aResponse string) *ErrorApplicationFailed {
+	return &ErrorApplicationFailed{
+		errorImpl: newError(fmt.Sprintf(eApplicationFailed, aApp, aResponse)),
+		App:       aApp,
+		Response:  aResponse,
+	}
+}
+
+// ErrorEventOverflow fired when OverflowDisconnect policy closes connection with full Messages() buffer
+type ErrorEventOverflow struct {
+	errorImpl
+	Size int
+}
+
+func newErrorEventOverflow(aSize int) *ErrorEventOverflow {
+	return &ErrorEventOverflow{
+		errorImpl: newError(fmt.Sprintf(eEventOverflow, aSize)),
+		Size:      aSize,
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/PSyton/goesl"
+	"github.com/PSyton/goesl/goesltest"
+)
+
+func TestWaitersGetDisconnectCause(t *testing.T) {
+	server, err := goesltest.NewServer("ClueCon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	release := make(chan struct{})
+	defer close(release)
+	server.HandleAPI(func(command string) string {
+		if command == "block" {
+			<-release
+		}
+		return "+OK"
+	})
+
+	client, err := goesl.NewClient(server.ConnectOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// not subscribed to BACKGROUND_JOB, so the job waits until connection drops
+	job, err := client.BgApi(ctx, "status")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apiErr := make(chan error, 1)
+	go func() {
+		_, err := client.Api(ctx, "block")
+		apiErr <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	server.DropConnections()
+
+	for name, err := range map[string]error{"api": <-apiErr, "job": waitJob(ctx, job)} {
+		if !errors.Is(err, goesl.ErrConnectionClosed) {
+			t.Errorf("%s: expected ErrConnectionClosed, got %v", name, err)
+		}
+		if !errors.Is(err, io.EOF) {
+			t.Errorf("%s: expected io.EOF cause, got %v", name, err)
+		}
+	}
+
+	if !errors.Is(client.Err(), io.EOF) {
+		t.Errorf("expected io.EOF terminal error, got %v", client.Err())
+	}
+}
+
+func TestLocalCloseIsConnectionClosed(t *testing.T) {
+	server, err := goesltest.NewServer("ClueCon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	client, err := goesl.NewClient(server.ConnectOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+
+	_, err = client.Api(context.Background(), "status")
+	var closed *goesl.ErrorConnectionClosed
+	if !errors.As(err, &closed) || !errors.Is(err, goesl.ErrConnectionClosed) {
+		t.Fatalf("expected ErrorConnectionClosed, got %v", err)
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	server, err := goesltest.NewServer("ClueCon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	opts := server.ConnectOptions()
+	opts.Password = "wrong"
+	if _, err := goesl.NewClient(opts); !errors.Is(err, goesl.ErrAuthFailed) {
+		t.Errorf("expected ErrAuthFailed, got %v", err)
+	}
+
+	client, err := goesl.NewClient(server.ConnectOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if _, err := client.Api(context.Background(), "unknown"); !errors.Is(err, goesl.ErrCommandRejected) {
+		t.Errorf("expected ErrCommandRejected, got %v", err)
+	}
+}
+
+func waitJob(ctx context.Context, job *goesl.Job) error {
+	_, err := job.Wait(ctx)
+	return err
+}
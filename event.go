@@ -0,0 +1,131 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl
+
+import (
+	"strconv"
+	"time"
+)
+
+// EventName - Value of Event-Name header
+type EventName string
+
+// Well known FreeSWITCH event names
+const (
+	EventCustom                 EventName = "CUSTOM"
+	EventAPI                    EventName = "API"
+	EventBackgroundJob          EventName = "BACKGROUND_JOB"
+	EventHeartbeat              EventName = "HEARTBEAT"
+	EventReSchedule             EventName = "RE_SCHEDULE"
+	EventStartup                EventName = "STARTUP"
+	EventShutdown               EventName = "SHUTDOWN"
+	EventReloadXML              EventName = "RELOADXML"
+	EventModuleLoad             EventName = "MODULE_LOAD"
+	EventModuleUnload           EventName = "MODULE_UNLOAD"
+	EventChannelCreate          EventName = "CHANNEL_CREATE"
+	EventChannelDestroy         EventName = "CHANNEL_DESTROY"
+	EventChannelState           EventName = "CHANNEL_STATE"
+	EventChannelCallstate       EventName = "CHANNEL_CALLSTATE"
+	EventChannelAnswer          EventName = "CHANNEL_ANSWER"
+	EventChannelHangup          EventName = "CHANNEL_HANGUP"
+	EventChannelHangupComplete  EventName = "CHANNEL_HANGUP_COMPLETE"
+	EventChannelExecute         EventName = "CHANNEL_EXECUTE"
+	EventChannelExecuteComplete EventName = "CHANNEL_EXECUTE_COMPLETE"
+	EventChannelBridge          EventName = "CHANNEL_BRIDGE"
+	EventChannelUnbridge        EventName = "CHANNEL_UNBRIDGE"
+	EventChannelProgress        EventName = "CHANNEL_PROGRESS"
+	EventChannelProgressMedia   EventName = "CHANNEL_PROGRESS_MEDIA"
+	EventChannelOriginate       EventName = "CHANNEL_ORIGINATE"
+	EventChannelPark            EventName = "CHANNEL_PARK"
+	EventChannelUnpark          EventName = "CHANNEL_UNPARK"
+	EventChannelHold            EventName = "CHANNEL_HOLD"
+	EventChannelUnhold          EventName = "CHANNEL_UNHOLD"
+	EventChannelData            EventName = "CHANNEL_DATA"
+	EventDTMF                   EventName = "DTMF"
+	EventPlaybackStart          EventName = "PLAYBACK_START"
+	EventPlaybackStop           EventName = "PLAYBACK_STOP"
+	EventRecordStart            EventName = "RECORD_START"
+	EventRecordStop             EventName = "RECORD_STOP"
+	EventPresenceIn             EventName = "PRESENCE_IN"
+	EventMessageWaiting         EventName = "MESSAGE_WAITING"
+)
+
+// CallDirection - Value of Call-Direction header
+type CallDirection string
+
+// Call directions
+const (
+	CallDirectionInbound  CallDirection = "inbound"
+	CallDirectionOutbound CallDirection = "outbound"
+)
+
+// Event - Message that carries FreeSWITCH event. Gives typed access to the common event headers
+type Event struct {
+	*Message
+}
+
+// AsEvent - Will return message as Event or error if message has no Event-Name header
+func (m *Message) AsEvent() (*Event, error) {
+	if m.GetHeader("Event-Name") == "" {
+		return nil, newErrorNotEvent(m.GetHeader("Content-Type"))
+	}
+
+	return &Event{Message: m}, nil
+}
+
+// Name - Will return Event-Name
+func (e *Event) Name() EventName {
+	return EventName(e.GetHeader("Event-Name"))
+}
+
+// Subclass - Will return Event-Subclass of CUSTOM events, like sofia::register
+func (e *Event) Subclass() string {
+	return e.GetHeader("Event-Subclass")
+}
+
+// UniqueID - Will return UUID of the channel event belongs to, or "" for non channel events
+func (e *Event) UniqueID() string {
+	if v := e.GetHeader("Unique-Id"); v != "" {
+		return v
+	}
+	return e.GetCallUUID()
+}
+
+// CoreUUID - Will return Core-UUID of FreeSWITCH instance that fired the event
+func (e *Event) CoreUUID() string {
+	return e.GetHeader("Core-Uuid")
+}
+
+// Timestamp - Will return Event-Date-Timestamp, or zero time if it is missing
+func (e *Event) Timestamp() time.Time {
+	return e.GetTime("Event-Date-Timestamp")
+}
+
+// GetTime - Will parse header holding microseconds since epoch (like Caller-Channel-Answered-Time).
+// Returns zero time if header is missing, invalid or zero.
+func (e *Event) GetTime(key string) time.Time {
+	usec, err := strconv.ParseInt(e.GetHeader(key), 10, 64)
+	if err != nil || usec <= 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, usec*int64(time.Microsecond))
+}
+
+// Sequence - Will return Event-Sequence, or 0 if it is missing
+func (e *Event) Sequence() uint64 {
+	seq, _ := strconv.ParseUint(e.GetHeader("Event-Sequence"), 10, 64)
+	return seq
+}
+
+// Direction - Will return Call-Direction (falls back to Caller-Direction)
+func (e *Event) Direction() CallDirection {
+	if v := e.GetHeader("Call-Direction"); v != "" {
+		return CallDirection(v)
+	}
+	return CallDirection(e.GetHeader("Caller-Direction"))
+}
@@ -0,0 +1,155 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl
+
+import (
+	"context"
+	"strings"
+)
+
+// ExecuteResult - CHANNEL_EXECUTE_COMPLETE event of application executed with ExecuteAndWait
+type ExecuteResult struct {
+	*Event
+}
+
+// Application - Will return name of executed application
+func (r *ExecuteResult) Application() string {
+	return r.GetHeader("Application")
+}
+
+// Response - Will return Application-Response
+func (r *ExecuteResult) Response() string {
+	return r.GetHeader("Application-Response")
+}
+
+// Variable - Will return channel variable as it was when application completed
+func (r *ExecuteResult) Variable(name string) string {
+	return r.GetHeader("Variable_" + strings.ToLower(name))
+}
+
+// execution is application waiting for its CHANNEL_EXECUTE_COMPLETE
+type execution struct {
+	app     string
+	channel string
+	result  chan replyResult
+}
+
+// ExecuteAndWait - Will execute application and wait until it completes. Connection must receive
+// CHANNEL_EXECUTE_COMPLETE and CHANNEL_HANGUP events of the channel (e.g. after MyEvents).
+// Waiting doesn't depend on Messages() being read, events arriving meanwhile are buffered for it.
+func (c *SocketConnection) ExecuteAndWait(ctx context.Context, app, args string) (*ExecuteResult, error) {
+	return c.ExecuteUUIDAndWait(ctx, "", app, args)
+}
+
+// ExecuteAndWait - Same as SocketConnection.ExecuteAndWait for the connected channel
+func (c *ESLConnection) ExecuteAndWait(ctx context.Context, app, args string) (*ExecuteResult, error) {
+	uuid := ""
+	if c.channelData != nil {
+		uuid = c.channelData.UniqueID()
+	}
+	return c.ExecuteUUIDAndWait(ctx, uuid, app, args)
+}
+
+// ExecuteUUIDAndWait - Will execute application on channel uuid and wait until it completes.
+// Returns ErrorChannelHangup if channel hangs up first.
+func (c *SocketConnection) ExecuteUUIDAndWait(ctx context.Context, uuid, app, args string) (*ExecuteResult, error) {
+	eventUUID := newUUID()
+
+	cmd, err := NewExecute(app, args).EventLock(true).EventUUID(eventUUID).UUID(uuid).Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	exec := c.registerExecution(eventUUID, uuid, app)
+	defer c.unregisterExecution(eventUUID)
+
+	reply, err := c.sendCommand(ctx, cmd, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := c.waitReply(ctx, reply); err != nil {
+		return nil, err
+	}
+
+	select {
+	case r := <-exec.result:
+		if r.err != nil {
+			return nil, r.err
+		}
+		return &ExecuteResult{Event: &Event{Message: r.msg}}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *SocketConnection) registerExecution(aEventUUID, aChannel, aApp string) *execution {
+	exec := &execution{
+		app:     aApp,
+		channel: aChannel,
+		result:  make(chan replyResult, 1),
+	}
+
+	c.execLock.Lock()
+	c.executions[aEventUUID] = exec
+	c.execLock.Unlock()
+	c.wakeReader()
+
+	return exec
+}
+
+func (c *SocketConnection) unregisterExecution(aEventUUID string) {
+	c.execLock.Lock()
+	delete(c.executions, aEventUUID)
+	c.execLock.Unlock()
+}
+
+// resolveExecution completes execution waiting for the message. Returns true if message is consumed.
+// Hangup events fail executions of the channel but are still delivered to Messages().
+func (c *SocketConnection) resolveExecution(aMsg *Message) bool {
+	switch EventName(aMsg.GetHeader("Event-Name")) {
+	case EventChannelExecuteComplete:
+		uuid := aMsg.GetHeader("Application-Uuid")
+
+		c.execLock.Lock()
+		exec, ok := c.executions[uuid]
+		delete(c.executions, uuid)
+		c.execLock.Unlock()
+
+		if ok {
+			exec.result <- replyResult{msg: aMsg}
+		}
+		return ok
+	case EventChannelHangup:
+		event := &Event{Message: aMsg}
+		channel := event.UniqueID()
+		err := newErrorChannelHangup(channel, aMsg.GetHeader("Hangup-Cause"))
+
+		c.execLock.Lock()
+		for uuid, exec := range c.executions {
+			// hangup application completes after CHANNEL_HANGUP
+			if exec.app == "hangup" || (exec.channel != "" && exec.channel != channel) {
+				continue
+			}
+			delete(c.executions, uuid)
+			exec.result <- replyResult{err: err}
+		}
+		c.execLock.Unlock()
+	}
+	return false
+}
+
+// failExecutions fails all waiting executions
+func (c *SocketConnection) failExecutions(aErr error) {
+	c.execLock.Lock()
+	defer c.execLock.Unlock()
+
+	for uuid, exec := range c.executions {
+		delete(c.executions, uuid)
+		exec.result <- replyResult{err: aErr}
+	}
+}
@@ -0,0 +1,14 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl
+
+import "time"
+
+// Backoff exposes delay before reconnect attempt to tests
+func (r *ReconnectingClient) Backoff(aAttempt int) time.Duration {
+	return r.backoff(aAttempt)
+}
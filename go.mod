@@ -1,5 +1,5 @@
 module github.com/PSyton/goesl
 
-go 1.12
+go 1.13
 
 require github.com/oklog/ulid/v2 v2.0.2
@@ -0,0 +1,94 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// urlUnsafe is the set of characters FreeSWITCH escapes in serialized headers
+const urlUnsafe = "\r\n \"#%&+:;<=>?@[\\]^`{|}"
+
+// escape url-encodes header value the way FreeSWITCH does
+func escape(aValue string) string {
+	var b strings.Builder
+	for i := 0; i < len(aValue); i++ {
+		c := aValue[i]
+		if c < 0x20 || c > 0x7e || strings.IndexByte(urlUnsafe, c) >= 0 {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// sortedKeys returns header names with Event-Name first, the way FreeSWITCH orders them
+func sortedKeys(aHeaders map[string]string) []string {
+	keys := make([]string, 0, len(aHeaders))
+	for k := range aHeaders {
+		if k != "Event-Name" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	if _, ok := aHeaders["Event-Name"]; ok {
+		keys = append([]string{"Event-Name"}, keys...)
+	}
+	return keys
+}
+
+func encodePlainEvent(aHeaders map[string]string, aBody string) string {
+	var b strings.Builder
+	for _, k := range sortedKeys(aHeaders) {
+		fmt.Fprintf(&b, "%s: %s\n", k, escape(aHeaders[k]))
+	}
+	if aBody != "" {
+		fmt.Fprintf(&b, "Content-Length: %d\n", len(aBody))
+	}
+	b.WriteString("\n")
+	b.WriteString(aBody)
+	return b.String()
+}
+
+func encodeJSONEvent(aHeaders map[string]string, aBody string) string {
+	event := make(map[string]string, len(aHeaders)+2)
+	for k, v := range aHeaders {
+		event[k] = v
+	}
+	if aBody != "" {
+		event["Content-Length"] = fmt.Sprint(len(aBody))
+		event["_body"] = aBody
+	}
+
+	data, _ := json.Marshal(event)
+	return string(data)
+}
+
+func encodeXMLEvent(aHeaders map[string]string, aBody string) string {
+	var b bytes.Buffer
+	b.WriteString("<event>\n  <headers>\n")
+	for _, k := range sortedKeys(aHeaders) {
+		fmt.Fprintf(&b, "    <%s>", k)
+		xml.EscapeText(&b, []byte(escape(aHeaders[k])))
+		fmt.Fprintf(&b, "</%s>\n", k)
+	}
+	b.WriteString("  </headers>\n")
+	if aBody != "" {
+		b.WriteString("  <body>")
+		xml.EscapeText(&b, []byte(aBody))
+		b.WriteString("</body>\n")
+	}
+	b.WriteString("</event>")
+	return b.String()
+}
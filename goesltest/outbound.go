@@ -0,0 +1,497 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesltest
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PSyton/goesl"
+)
+
+// Execution - Application execute requested by outbound handler through sendmsg
+type Execution struct {
+	App       string
+	Args      string
+	EventUUID string
+	EventLock bool
+	Loops     int
+	Headers   map[string]string
+}
+
+// defaultAppResponses are Application-Response values FreeSWITCH reports for successful applications.
+// Other applications report _none_
+var defaultAppResponses = map[string]string{
+	"playback": "FILE PLAYED",
+}
+
+// Call - Scripted FreeSWITCH channel connected to ESLServer like `socket` dialplan app does.
+// Replies to every command and emits channel events for executed applications.
+type Call struct {
+	ss   *session
+	data map[string]string
+
+	lock         sync.Mutex
+	commands     []string
+	executions   []Execution
+	appResponses map[string]string
+	appDelays    map[string]time.Duration
+	apiResponses map[string]string
+	sequence     int
+	linger       bool
+	hungUp       bool
+	hangupCh     chan struct{}
+
+	done chan struct{}
+}
+
+// NewChannelData - Will return realistic channel data of inbound call with specified uuid
+func NewChannelData(uuid string) map[string]string {
+	return map[string]string{
+		"Event-Name":                "CHANNEL_DATA",
+		"Core-UUID":                 "6d9f3ab2-4e3a-4a35-9d0c-5b4f1b1f0c11",
+		"Unique-ID":                 uuid,
+		"Channel-Call-UUID":         uuid,
+		"Caller-Unique-ID":          uuid,
+		"Channel-Name":              "sofia/internal/1000@127.0.0.1",
+		"Channel-State":             "CS_EXECUTE",
+		"Channel-Call-State":        "RINGING",
+		"Answer-State":              "ringing",
+		"Call-Direction":            "inbound",
+		"Caller-Direction":          "inbound",
+		"Caller-Caller-ID-Name":     "Test Caller",
+		"Caller-Caller-ID-Number":   "1000",
+		"Caller-Destination-Number": "1001",
+		"Caller-Context":            "default",
+		"Caller-Network-Addr":       "127.0.0.1",
+		"variable_sip_from_user":    "1000",
+		"variable_sip_to_user":      "1001",
+		"variable_direction":        "inbound",
+		"variable_uuid":             uuid,
+	}
+}
+
+// DialOutbound - Will connect to outbound ESLServer at addr, answer its `connect` with channel data
+// (merged over NewChannelData defaults) and keep serving the handler in background.
+func DialOutbound(addr string, channelData map[string]string) (*Call, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return startCall(conn, channelData)
+}
+
+// DialOutboundTLS - Same as DialOutbound but connects to ESLServer over TLS
+func DialOutboundTLS(addr string, config *tls.Config, channelData map[string]string) (*Call, error) {
+	conn, err := tls.Dial("tcp", addr, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return startCall(conn, channelData)
+}
+
+func startCall(conn net.Conn, channelData map[string]string) (*Call, error) {
+	uuid := channelData["Unique-ID"]
+	if uuid == "" {
+		uuid = fmt.Sprintf("00000000-0000-4000-8000-%012d", time.Now().UnixNano()%1000000000000)
+	}
+
+	data := NewChannelData(uuid)
+	for k, v := range channelData {
+		data[k] = v
+	}
+
+	c := &Call{
+		ss:           newSession(conn),
+		data:         data,
+		appResponses: make(map[string]string),
+		appDelays:    make(map[string]time.Duration),
+		apiResponses: make(map[string]string),
+		hangupCh:     make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+
+	cmd, _, _, err := c.ss.readCommand()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	c.record(cmd)
+
+	if cmd != "connect" {
+		conn.Close()
+		return nil, fmt.Errorf("expected connect, got %q", cmd)
+	}
+
+	if err := c.replyConnect(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	go c.serve()
+	return c, nil
+}
+
+// UUID - Will return channel unique id
+func (c *Call) UUID() string {
+	return c.data["Unique-ID"]
+}
+
+// SetAppResponse - Will set Application-Response reported in CHANNEL_EXECUTE_COMPLETE for the application.
+// By default successful response of the application is reported, e.g. FILE PLAYED for playback
+func (c *Call) SetAppResponse(app, response string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.appResponses[app] = response
+}
+
+// SetAppDelay - Will make application run for duration before it completes. Hangup interrupts it
+func (c *Call) SetAppDelay(app string, delay time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.appDelays[app] = delay
+}
+
+// SetAPIResponse - Will set api/response body for exact api command
+func (c *Call) SetAPIResponse(command, body string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.apiResponses[command] = body
+}
+
+// SetVariable - Will set channel variable reported in channel data and events
+func (c *Call) SetVariable(name, value string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.data["variable_"+name] = value
+}
+
+// Commands - Will return command lines received from handler, in order
+func (c *Call) Commands() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([]string(nil), c.commands...)
+}
+
+// Executions - Will return applications handler executed, in order
+func (c *Call) Executions() []Execution {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([]Execution(nil), c.executions...)
+}
+
+// Done - Will return channel closed once handler side closes the connection
+func (c *Call) Done() <-chan struct{} {
+	return c.done
+}
+
+// Hangup - Will hang up the channel from the far end, e.g. caller dropped.
+// Connection is closed afterwards unless handler asked to linger.
+func (c *Call) Hangup(cause string) {
+	c.hangup(cause)
+
+	if c.shouldDisconnect() {
+		c.disconnect()
+		c.ss.conn.Close()
+	}
+}
+
+// PushEvent - Will send event to the handler if it subscribed to it
+func (c *Call) PushEvent(headers map[string]string, body string) error {
+	return c.ss.fireEvent(c.eventHeaders(headers["Event-Name"], headers), body)
+}
+
+// Close - Will drop the connection
+func (c *Call) Close() {
+	c.ss.conn.Close()
+	<-c.done
+}
+
+func (c *Call) record(aCmd string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.commands = append(c.commands, aCmd)
+}
+
+func (c *Call) replyConnect() error {
+	c.lock.Lock()
+	reply := make(map[string]string, len(c.data)+4)
+	for k, v := range c.data {
+		reply[k] = v
+	}
+	c.lock.Unlock()
+
+	// FreeSWITCH serializes CHANNEL_DATA event as reply, so every value is url-encoded
+	reply["Content-Type"] = "command/reply"
+	reply["Reply-Text"] = "+OK\n"
+	reply["Socket-Mode"] = "async"
+	reply["Control"] = "full"
+
+	headers := make([]string, 0, len(reply)*2)
+	for _, k := range sortedKeys(reply) {
+		headers = append(headers, k, escape(reply[k]))
+	}
+	return c.ss.writeFrame(headers, "")
+}
+
+// eventHeaders builds event with channel data and common event headers
+func (c *Call) eventHeaders(aName string, aExtra map[string]string) map[string]string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.sequence++
+	headers := make(map[string]string, len(c.data)+len(aExtra)+4)
+	for k, v := range c.data {
+		headers[k] = v
+	}
+	headers["Event-Name"] = aName
+	headers["Event-Sequence"] = strconv.Itoa(c.sequence)
+	headers["Event-Date-Timestamp"] = strconv.FormatInt(time.Now().UnixNano()/int64(time.Microsecond), 10)
+	for k, v := range aExtra {
+		headers[k] = v
+	}
+	return headers
+}
+
+func (c *Call) serve() {
+	defer close(c.done)
+	defer c.ss.conn.Close()
+
+	for {
+		cmd, headers, body, err := c.ss.readCommand()
+		if err != nil {
+			return
+		}
+		c.record(cmd)
+
+		name, args := splitCommand(cmd)
+
+		switch name {
+		case "sendmsg":
+			c.sendmsg(headers, body)
+		case "api":
+			c.lock.Lock()
+			response, ok := c.apiResponses[args]
+			c.lock.Unlock()
+			if !ok {
+				response = "-ERR " + strings.Fields(args + " ")[0] + " Command not found!\n"
+			}
+			c.ss.writeFrame([]string{"Content-Type", "api/response"}, response)
+		case "myevents":
+			format := "plain"
+			if args == "json" || args == "xml" {
+				format = args
+			}
+			c.ss.subscribe(format + " ALL")
+			c.ss.reply("+OK Events Enabled")
+		case "event":
+			if err := c.ss.subscribe(args); err != nil {
+				c.ss.reply("-ERR " + err.Error())
+				continue
+			}
+			c.ss.reply("+OK event listener enabled " + string(c.ss.format))
+		case "nixevent":
+			c.ss.unsubscribe(args)
+			c.ss.reply("+OK events nixed")
+		case "noevents":
+			c.ss.unsubscribe("")
+			c.ss.reply("+OK no longer listening for events")
+		case "linger":
+			c.setLinger(true)
+			c.ss.reply("+OK will linger")
+		case "nolinger":
+			c.setLinger(false)
+			c.ss.reply("+OK will not linger")
+		case "exit":
+			c.ss.reply("+OK bye")
+			c.disconnect()
+			return
+		default:
+			c.ss.reply("+OK")
+		}
+
+		if c.shouldDisconnect() {
+			c.disconnect()
+			return
+		}
+	}
+}
+
+func (c *Call) setLinger(aLinger bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.linger = aLinger
+}
+
+// shouldDisconnect is true once channel is gone and handler doesn't linger
+func (c *Call) shouldDisconnect() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.hungUp && !c.linger
+}
+
+func (c *Call) disconnect() {
+	c.ss.writeFrame([]string{"Content-Type", "text/disconnect-notice", "Content-Disposition", "disconnect"}, "Disconnected, goodbye.\nSee you at ClueCon! http://www.cluecon.com/\n")
+}
+
+func (c *Call) sendmsg(aHeaders map[string]string, aBody string) {
+	c.lock.Lock()
+	hungUp := c.hungUp
+	c.lock.Unlock()
+
+	if hungUp {
+		c.ss.reply("-ERR invalid session id [" + c.UUID() + "]")
+		return
+	}
+
+	switch strings.ToLower(aHeaders["Call-Command"]) {
+	case "execute":
+		c.ss.reply("+OK")
+		c.execute(aHeaders, aBody)
+	case "hangup":
+		c.ss.reply("+OK")
+		c.hangup(aHeaders["Hangup-Cause"])
+	default:
+		c.ss.reply("+OK")
+	}
+}
+
+func (c *Call) execute(aHeaders map[string]string, aBody string) {
+	exec := Execution{
+		App:       aHeaders["Execute-App-Name"],
+		Args:      aHeaders["Execute-App-Arg"],
+		EventUUID: aHeaders["Event-Uuid"],
+		EventLock: aHeaders["Event-Lock"] == "true",
+		Loops:     1,
+		Headers:   aHeaders,
+	}
+	if aBody != "" && exec.Args == "" {
+		exec.Args = aBody
+	}
+	if loops, err := strconv.Atoi(aHeaders["Loops"]); err == nil {
+		exec.Loops = loops
+	}
+	if exec.EventUUID == "" {
+		exec.EventUUID = fmt.Sprintf("%s-exec-%d", c.UUID(), len(c.Executions())+1)
+	}
+
+	c.lock.Lock()
+	c.executions = append(c.executions, exec)
+	delay := c.appDelays[exec.App]
+	c.lock.Unlock()
+
+	app := map[string]string{
+		"Application":      exec.App,
+		"Application-Data": exec.Args,
+		"Application-UUID": exec.EventUUID,
+	}
+
+	c.ss.fireEvent(c.eventHeaders(string(goesl.EventChannelExecute), app), "")
+
+	if delay <= 0 {
+		c.complete(exec, app)
+		return
+	}
+
+	go func() {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-c.hangupCh:
+		case <-c.done:
+			return
+		}
+		c.complete(exec, app)
+	}()
+}
+
+// complete finishes application and emits CHANNEL_EXECUTE_COMPLETE
+func (c *Call) complete(exec Execution, app map[string]string) {
+	c.lock.Lock()
+	response, ok := c.appResponses[exec.App]
+	c.lock.Unlock()
+
+	if !ok {
+		response, ok = defaultAppResponses[exec.App]
+	}
+	if !ok {
+		response = "_none_"
+	}
+
+	switch exec.App {
+	case "answer":
+		c.lock.Lock()
+		c.data["Answer-State"] = "answered"
+		c.data["Channel-Call-State"] = "ACTIVE"
+		c.lock.Unlock()
+		c.ss.fireEvent(c.eventHeaders(string(goesl.EventChannelAnswer), nil), "")
+	case "set":
+		if kv := strings.SplitN(exec.Args, "=", 2); len(kv) == 2 {
+			c.SetVariable(kv[0], kv[1])
+		}
+	case "hangup":
+		if c.markHungUp(exec.Args) {
+			c.ss.fireEvent(c.eventHeaders(string(goesl.EventChannelHangup), nil), "")
+		}
+	}
+
+	app["Application-Response"] = response
+	c.ss.fireEvent(c.eventHeaders(string(goesl.EventChannelExecuteComplete), app), "")
+
+	if exec.App == "hangup" {
+		c.ss.fireEvent(c.eventHeaders(string(goesl.EventChannelHangupComplete), nil), "")
+	}
+}
+
+// markHungUp updates channel state, returns false if channel was already hung up
+func (c *Call) markHungUp(aCause string) bool {
+	if aCause == "" {
+		aCause = "NORMAL_CLEARING"
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.hungUp {
+		return false
+	}
+
+	c.hungUp = true
+	close(c.hangupCh)
+	c.data["Answer-State"] = "hangup"
+	c.data["Channel-Call-State"] = "HANGUP"
+	c.data["Channel-State"] = "CS_HANGUP"
+	c.data["Hangup-Cause"] = aCause
+	return true
+}
+
+func (c *Call) hangup(aCause string) {
+	if !c.markHungUp(aCause) {
+		return
+	}
+
+	c.ss.fireEvent(c.eventHeaders(string(goesl.EventChannelHangup), nil), "")
+	c.ss.fireEvent(c.eventHeaders(string(goesl.EventChannelHangupComplete), nil), "")
+}
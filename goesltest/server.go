@@ -0,0 +1,537 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+// Package goesltest provides fake FreeSWITCH event socket endpoints for testing code built on goesl.
+package goesltest
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/PSyton/goesl"
+)
+
+var errServerClosed = errors.New("goesltest: server closed")
+
+// APIHandler - Returns api/response body for the api command (without `api ` prefix)
+type APIHandler func(command string) string
+
+// CommandHandler - Returns Reply-Text for command that is not api/bgapi/auth/exit. Headers are sent after command line
+type CommandHandler func(command string, headers map[string]string) string
+
+// Server - Fake FreeSWITCH that accepts inbound event socket connections on localhost
+type Server struct {
+	listener net.Listener
+	password string
+
+	lock         sync.Mutex
+	users        map[string]string
+	rude         bool
+	apiResponses map[string]string
+	apiHandler   APIHandler
+	cmdHandler   CommandHandler
+	commands     []string
+	sessions     map[*session]struct{}
+	closed       bool
+	wg           sync.WaitGroup
+}
+
+// NewServer - Will start fake FreeSWITCH listening on random localhost port and accepting the password
+func NewServer(password string) (*Server, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	return newServer(l, password), nil
+}
+
+// NewTLSServer - Same as NewServer but accepts TLS connections only. Clients have to set
+// ConnectOptions.TLSConfig themselves (see NewCertificates)
+func NewTLSServer(password string, config *tls.Config) (*Server, error) {
+	l, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	if err != nil {
+		return nil, err
+	}
+
+	return newServer(l, password), nil
+}
+
+func newServer(l net.Listener, password string) *Server {
+	s := &Server{
+		listener:     l,
+		password:     password,
+		users:        make(map[string]string),
+		apiResponses: make(map[string]string),
+		sessions:     make(map[*session]struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Addr - Will return host:port server listens on
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// ConnectOptions - Will return goesl options that connect to this server with the right password
+func (s *Server) ConnectOptions() goesl.ConnectOptions {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	return goesl.ConnectOptions{
+		Host:     addr.IP.String(),
+		Port:     uint(addr.Port),
+		Password: s.password,
+	}
+}
+
+// AddUser - Will accept `userauth user@domain:password` for the user
+func (s *Server) AddUser(user, password string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.users[user] = password
+}
+
+// SetRudeRejection - Will make server deny new connections with text/rude-rejection, like ESL ACL does
+func (s *Server) SetRudeRejection(reject bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.rude = reject
+}
+
+// SetAPIResponse - Will set canned api/response body for exact api command
+func (s *Server) SetAPIResponse(command, body string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.apiResponses[command] = body
+}
+
+// HandleAPI - Will set handler for api commands without canned response
+func (s *Server) HandleAPI(handler APIHandler) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.apiHandler = handler
+}
+
+// HandleCommand - Will set handler for all other commands. By default they are replied with +OK
+func (s *Server) HandleCommand(handler CommandHandler) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.cmdHandler = handler
+}
+
+// Commands - Will return command lines received from all clients so far, in order
+func (s *Server) Commands() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]string(nil), s.commands...)
+}
+
+// Sessions - Will return number of connected clients
+func (s *Server) Sessions() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return len(s.sessions)
+}
+
+// PushEvent - Will send event in specified format to every connected client, whether it subscribed or not
+func (s *Server) PushEvent(format goesl.EventFormat, headers map[string]string, body string) error {
+	for _, ss := range s.activeSessions() {
+		if err := ss.sendEvent(format, headers, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Disconnect - Will send text/disconnect-notice and close every client connection
+func (s *Server) Disconnect() {
+	for _, ss := range s.activeSessions() {
+		ss.writeFrame([]string{"Content-Type", "text/disconnect-notice"}, "Disconnected, goodbye.\nSee you at ClueCon! http://www.cluecon.com/\n")
+		ss.conn.Close()
+	}
+}
+
+// DropConnections - Will close every client connection without notice, like crashed FreeSWITCH
+func (s *Server) DropConnections() {
+	for _, ss := range s.activeSessions() {
+		ss.conn.Close()
+	}
+}
+
+// Close - Will stop listening, drop all clients and wait for them to finish
+func (s *Server) Close() error {
+	s.lock.Lock()
+	s.closed = true
+	s.lock.Unlock()
+
+	err := s.listener.Close()
+	s.DropConnections()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) activeSessions() []*session {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	result := make([]*session, 0, len(s.sessions))
+	for ss := range s.sessions {
+		result = append(result, ss)
+	}
+	return result
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		c, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		if !s.accept(c) {
+			return
+		}
+	}
+}
+
+// accept starts session over c unless server is closed
+func (s *Server) accept(c net.Conn) bool {
+	ss := newSession(c)
+
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		c.Close()
+		return false
+	}
+	s.sessions[ss] = struct{}{}
+	s.wg.Add(1)
+	s.lock.Unlock()
+
+	go func() {
+		defer s.wg.Done()
+		s.handle(ss)
+
+		s.lock.Lock()
+		delete(s.sessions, ss)
+		s.lock.Unlock()
+		ss.conn.Close()
+	}()
+	return true
+}
+
+// DialContext - Will serve new in-memory session over net.Pipe ignoring the address, so Server can be used
+// as ConnectOptions.Dialer or its connection passed to goesl.NewClientFromConn
+func (s *Server) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	client, server := net.Pipe()
+	if !s.accept(server) {
+		client.Close()
+		return nil, errServerClosed
+	}
+	return client, nil
+}
+
+func (s *Server) record(aCmd string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.commands = append(s.commands, aCmd)
+}
+
+func (s *Server) api(aCmd string) string {
+	s.lock.Lock()
+	body, ok := s.apiResponses[aCmd]
+	handler := s.apiHandler
+	s.lock.Unlock()
+
+	if ok {
+		return body
+	}
+	if handler != nil {
+		return handler(aCmd)
+	}
+	return "-ERR " + strings.Fields(aCmd + " ")[0] + " Command not found!\n"
+}
+
+func (s *Server) handle(ss *session) {
+	s.lock.Lock()
+	rude := s.rude
+	s.lock.Unlock()
+
+	if rude {
+		ss.writeFrame([]string{"Content-Type", "text/rude-rejection"}, "Access Denied, go away.\n")
+		return
+	}
+
+	if err := ss.writeFrame([]string{"Content-Type", "auth/request"}, ""); err != nil {
+		return
+	}
+
+	authed := false
+	for {
+		cmd, headers, _, err := ss.readCommand()
+		if err != nil {
+			return
+		}
+		s.record(cmd)
+
+		name, args := splitCommand(cmd)
+
+		if !authed {
+			if !s.checkAuth(name, args) {
+				ss.reply("-ERR invalid")
+				ss.writeFrame([]string{"Content-Type", "text/disconnect-notice"}, "Disconnected, goodbye.\n")
+				return
+			}
+			authed = true
+			ss.reply("+OK accepted")
+			continue
+		}
+
+		switch name {
+		case "api":
+			ss.writeFrame([]string{"Content-Type", "api/response"}, s.api(args))
+		case "bgapi":
+			jobUUID := headers["Job-Uuid"]
+			if jobUUID == "" {
+				jobUUID = fmt.Sprintf("job-%d", len(s.Commands()))
+			}
+			ss.writeFrame([]string{"Content-Type", "command/reply", "Reply-Text", "+OK Job-UUID: " + jobUUID, "Job-UUID", jobUUID}, "")
+			apiName, apiArgs := splitCommand(args)
+			ss.fireEvent(map[string]string{
+				"Event-Name":      string(goesl.EventBackgroundJob),
+				"Job-UUID":        jobUUID,
+				"Job-Command":     apiName,
+				"Job-Command-Arg": apiArgs,
+			}, s.api(args))
+		case "event":
+			if err := ss.subscribe(args); err != nil {
+				ss.reply("-ERR " + err.Error())
+				continue
+			}
+			ss.reply("+OK event listener enabled " + string(ss.format))
+		case "nixevent":
+			ss.unsubscribe(args)
+			ss.reply("+OK events nixed")
+		case "noevents":
+			ss.unsubscribe("")
+			ss.reply("+OK no longer listening for events")
+		case "exit":
+			ss.reply("+OK bye")
+			ss.writeFrame([]string{"Content-Type", "text/disconnect-notice"}, "Disconnected, goodbye.\n")
+			return
+		default:
+			s.lock.Lock()
+			handler := s.cmdHandler
+			s.lock.Unlock()
+
+			reply := "+OK"
+			if handler != nil {
+				reply = handler(cmd, headers)
+			}
+			ss.reply(reply)
+		}
+	}
+}
+
+// checkAuth validates `auth password` or `userauth user@domain:password`
+func (s *Server) checkAuth(aName, aArgs string) bool {
+	switch aName {
+	case "auth":
+		return aArgs == s.password
+	case "userauth":
+		parts := strings.SplitN(aArgs, ":", 2)
+		if len(parts) != 2 {
+			return false
+		}
+
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		password, ok := s.users[parts[0]]
+		return ok && password == parts[1]
+	}
+	return false
+}
+
+func splitCommand(aCmd string) (string, string) {
+	parts := strings.SplitN(aCmd, " ", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// session is single event socket connection on FreeSWITCH side
+type session struct {
+	conn   net.Conn
+	reader *textproto.Reader
+
+	writeLock sync.Mutex
+
+	lock   sync.Mutex
+	format goesl.EventFormat
+	events map[string]bool
+}
+
+func newSession(aConn net.Conn) *session {
+	return &session{
+		conn:   aConn,
+		reader: textproto.NewReader(bufio.NewReader(aConn)),
+		events: make(map[string]bool),
+	}
+}
+
+// readCommand reads command line, its headers and body (when Content-Length header is set)
+func (ss *session) readCommand() (string, map[string]string, string, error) {
+	var cmd string
+	for cmd == "" {
+		line, err := ss.reader.ReadLine()
+		if err != nil {
+			return "", nil, "", err
+		}
+		cmd = strings.TrimSpace(line)
+	}
+
+	headers := make(map[string]string)
+	for {
+		line, err := ss.reader.ReadLine()
+		if err != nil {
+			return "", nil, "", err
+		}
+		if line == "" {
+			break
+		}
+		if i := strings.Index(line, ":"); i > 0 {
+			headers[textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(line[:i]))] = strings.TrimSpace(line[i+1:])
+		}
+	}
+
+	var body string
+	if v := headers["Content-Length"]; v != "" {
+		length, err := strconv.Atoi(v)
+		if err != nil {
+			return "", nil, "", err
+		}
+		buf := make([]byte, length)
+		if _, err := io.ReadFull(ss.reader.R, buf); err != nil {
+			return "", nil, "", err
+		}
+		body = string(buf)
+	}
+
+	return cmd, headers, body, nil
+}
+
+// writeFrame writes header pairs and body, adding Content-Length for non empty body
+func (ss *session) writeFrame(aHeaders []string, aBody string) error {
+	var b strings.Builder
+	for i := 0; i+1 < len(aHeaders); i += 2 {
+		fmt.Fprintf(&b, "%s: %s\n", aHeaders[i], aHeaders[i+1])
+	}
+	if aBody != "" {
+		fmt.Fprintf(&b, "Content-Length: %d\n", len(aBody))
+	}
+	b.WriteString("\n")
+	b.WriteString(aBody)
+
+	ss.writeLock.Lock()
+	defer ss.writeLock.Unlock()
+
+	_, err := ss.conn.Write([]byte(b.String()))
+	return err
+}
+
+func (ss *session) reply(aText string) error {
+	return ss.writeFrame([]string{"Content-Type", "command/reply", "Reply-Text", aText}, "")
+}
+
+func (ss *session) subscribe(aArgs string) error {
+	fields := strings.Fields(aArgs)
+	if len(fields) == 0 {
+		return fmt.Errorf("missing format")
+	}
+
+	format := goesl.EventFormat(strings.ToLower(fields[0]))
+	switch format {
+	case goesl.EventFormatPlain, goesl.EventFormatJSON, goesl.EventFormatXML:
+	default:
+		return fmt.Errorf("invalid format %s", fields[0])
+	}
+
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+
+	ss.format = format
+	for _, e := range fields[1:] {
+		ss.events[e] = true
+	}
+	return nil
+}
+
+func (ss *session) unsubscribe(aArgs string) {
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+
+	if aArgs == "" {
+		ss.events = make(map[string]bool)
+		return
+	}
+	for _, e := range strings.Fields(aArgs) {
+		delete(ss.events, e)
+	}
+}
+
+// fireEvent sends event only if session is subscribed to it, like FreeSWITCH does
+func (ss *session) fireEvent(aHeaders map[string]string, aBody string) error {
+	ss.lock.Lock()
+	format := ss.format
+	subscribed := ss.events["ALL"] || ss.events[aHeaders["Event-Name"]]
+	if aHeaders["Event-Name"] == string(goesl.EventCustom) {
+		subscribed = subscribed || ss.events[aHeaders["Event-Subclass"]]
+	}
+	ss.lock.Unlock()
+
+	if !subscribed {
+		return nil
+	}
+	return ss.sendEvent(format, aHeaders, aBody)
+}
+
+func (ss *session) sendEvent(aFormat goesl.EventFormat, aHeaders map[string]string, aBody string) error {
+	switch aFormat {
+	case goesl.EventFormatJSON:
+		return ss.writeFrame([]string{"Content-Type", "text/event-json"}, encodeJSONEvent(aHeaders, aBody))
+	case goesl.EventFormatXML:
+		return ss.writeFrame([]string{"Content-Type", "text/event-xml"}, encodeXMLEvent(aHeaders, aBody))
+	default:
+		return ss.writeFrame([]string{"Content-Type", "text/event-plain"}, encodePlainEvent(aHeaders, aBody))
+	}
+}
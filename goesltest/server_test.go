@@ -0,0 +1,163 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesltest_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/PSyton/goesl"
+	"github.com/PSyton/goesl/goesltest"
+)
+
+func newServer(t *testing.T) *goesltest.Server {
+	t.Helper()
+
+	server, err := goesltest.NewServer("ClueCon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server
+}
+
+func testContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), 5*time.Second)
+}
+
+// nextMessage returns next message of the client or fails when there is none in time
+func nextMessage(t *testing.T, client *goesl.Client) *goesl.Message {
+	t.Helper()
+
+	select {
+	case msg, ok := <-client.Messages():
+		if !ok {
+			t.Fatalf("messages closed: %v", client.Err())
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+	return nil
+}
+
+func TestServerApiAndBgApi(t *testing.T) {
+	server := newServer(t)
+	defer server.Close()
+	server.SetAPIResponse("status", "UP 0 years")
+	server.HandleAPI(func(command string) string {
+		return "+OK handled " + command
+	})
+
+	client, err := goesl.NewClient(server.ConnectOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, cancel := testContext()
+	defer cancel()
+
+	msg, err := client.Api(ctx, "status")
+	if err != nil || msg.GetReplyText() != "UP 0 years" {
+		t.Fatalf("canned api: got %v, %v", msg, err)
+	}
+
+	msg, err = client.Api(ctx, "reloadxml")
+	if err != nil || msg.GetReplyText() != "+OK handled reloadxml" {
+		t.Fatalf("handled api: got %v, %v", msg, err)
+	}
+
+	if err := client.Subscribe(ctx, goesl.EventFormatPlain, string(goesl.EventBackgroundJob)); err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := client.BgApi(ctx, "status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := job.Wait(ctx)
+	if err != nil || result.GetReplyText() != "UP 0 years" {
+		t.Fatalf("bgapi: got %v, %v", result, err)
+	}
+}
+
+func TestServerSubscribeAndPushEvent(t *testing.T) {
+	server := newServer(t)
+	defer server.Close()
+
+	client, err := goesl.NewClient(server.ConnectOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, cancel := testContext()
+	defer cancel()
+
+	if err := client.Subscribe(ctx, goesl.EventFormatJSON, "CHANNEL_ANSWER"); err != nil {
+		t.Fatal(err)
+	}
+	if subs := client.Subscriptions(); subs.Format != goesl.EventFormatJSON || len(subs.Events) != 1 {
+		t.Fatalf("unexpected subscriptions %+v", subs)
+	}
+
+	headers := map[string]string{
+		"Event-Name":        "CHANNEL_ANSWER",
+		"Unique-ID":         "uuid-1",
+		"variable_sip_user": "1000 & co",
+	}
+	for _, format := range []goesl.EventFormat{goesl.EventFormatPlain, goesl.EventFormatJSON} {
+		if err := server.PushEvent(format, headers, "body text"); err != nil {
+			t.Fatal(err)
+		}
+
+		ev, err := nextMessage(t, client).AsEvent()
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if ev.Name() != goesl.EventChannelAnswer || ev.UniqueID() != "uuid-1" {
+			t.Errorf("%s: unexpected event %s", format, ev)
+		}
+		if got := ev.GetHeader("Variable_sip_user"); got != "1000 & co" {
+			t.Errorf("%s: expected decoded variable, got %q", format, got)
+		}
+		if got := string(ev.Body); got != "body text" {
+			t.Errorf("%s: expected body, got %q", format, got)
+		}
+	}
+}
+
+func TestServerDisconnect(t *testing.T) {
+	server := newServer(t)
+	defer server.Close()
+
+	client, err := goesl.NewClient(server.ConnectOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	server.Disconnect()
+
+	if msg := nextMessage(t, client); msg.GetHeader("Content-Type") != "text/disconnect-notice" {
+		t.Fatalf("expected disconnect notice, got %s", msg)
+	}
+	if _, ok := <-client.Messages(); ok {
+		t.Fatal("expected messages to be closed")
+	}
+
+	<-client.Done()
+	if !errors.Is(client.Err(), io.EOF) {
+		t.Fatalf("expected EOF, got %v", client.Err())
+	}
+	if server.Sessions() != 0 {
+		t.Fatalf("expected no sessions, got %d", server.Sessions())
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesltest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"time"
+)
+
+// Certificates - Self-signed CA with server and client certificates issued by it, valid for localhost
+type Certificates struct {
+	CA     *x509.Certificate
+	Server tls.Certificate
+	Client tls.Certificate
+
+	pool *x509.CertPool
+}
+
+// NewCertificates - Will generate fresh CA, server and client certificates
+func NewCertificates() (*Certificates, error) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	caTemplate := certificateTemplate("goesltest CA")
+	caTemplate.IsCA = true
+	caTemplate.BasicConstraintsValid = true
+	caTemplate.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return nil, err
+	}
+
+	server, err := issueCertificate(ca, caKey, "localhost", x509.ExtKeyUsageServerAuth)
+	if err != nil {
+		return nil, err
+	}
+	client, err := issueCertificate(ca, caKey, "goesltest client", x509.ExtKeyUsageClientAuth)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	return &Certificates{CA: ca, Server: server, Client: client, pool: pool}, nil
+}
+
+// ServerConfig - Will return server side config that requires and verifies client certificate
+func (c *Certificates) ServerConfig() *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{c.Server},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    c.pool,
+	}
+}
+
+// ClientConfig - Will return client side config that trusts the CA and presents client certificate
+func (c *Certificates) ClientConfig() *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{c.Client},
+		RootCAs:      c.pool,
+		ServerName:   "localhost",
+	}
+}
+
+// AnonymousClientConfig - Same as ClientConfig but without client certificate
+func (c *Certificates) AnonymousClientConfig() *tls.Config {
+	return &tls.Config{
+		RootCAs:    c.pool,
+		ServerName: "localhost",
+	}
+}
+
+func certificateTemplate(aName string) *x509.Certificate {
+	serial, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 62))
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: aName, Organization: []string{"goesltest"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+}
+
+func issueCertificate(aCA *x509.Certificate, aKey *ecdsa.PrivateKey, aName string, aUsage x509.ExtKeyUsage) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	template := certificateTemplate(aName)
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{aUsage}
+	if aUsage == x509.ExtKeyUsageServerAuth {
+		template.DNSNames = []string{"localhost"}
+		template.IPAddresses = []net.IP{net.ParseIP("127.0.0.1"), net.IPv6loopback}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, aCA, &key.PublicKey, aKey)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesltest_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/PSyton/goesl"
+	"github.com/PSyton/goesl/goesltest"
+)
+
+func newCertificates(t *testing.T) *goesltest.Certificates {
+	t.Helper()
+
+	certs, err := goesltest.NewCertificates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certs
+}
+
+func TestInboundTLS(t *testing.T) {
+	certs := newCertificates(t)
+
+	server, err := goesltest.NewTLSServer("ClueCon", certs.ServerConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	server.SetAPIResponse("status", "UP")
+
+	opts := server.ConnectOptions()
+	opts.TLSConfig = certs.ClientConfig()
+
+	client, err := goesl.NewClient(opts)
+	if err != nil {
+		t.Fatalf("client with certificate: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := testContext()
+	defer cancel()
+
+	if msg, err := client.Api(ctx, "status"); err != nil || msg.GetReplyText() != "UP" {
+		t.Fatalf("got %v, %v", msg, err)
+	}
+
+	opts.TLSConfig = certs.AnonymousClientConfig()
+	if anonymous, err := goesl.NewClient(opts); err == nil {
+		anonymous.Close()
+		t.Fatal("expected client without certificate to be rejected")
+	}
+}
+
+func TestOutboundTLS(t *testing.T) {
+	certs := newCertificates(t)
+
+	var handled int32
+	server := goesl.NewESLServerWithOptions(goesl.ServerOptions{
+		TLSConfig:           certs.ServerConfig(),
+		TLSHandshakeTimeout: 2 * time.Second,
+	})
+	if err := server.Start("127.0.0.1:0", func(conn *goesl.ESLConnection) bool {
+		atomic.AddInt32(&handled, 1)
+		if err := conn.Execute("answer", "", true); err != nil {
+			t.Errorf("answer: %v", err)
+		}
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+
+	call, err := goesltest.DialOutboundTLS(server.Addr(), certs.ClientConfig(), nil)
+	if err != nil {
+		t.Fatalf("call with certificate: %v", err)
+	}
+	defer call.Close()
+
+	select {
+	case <-call.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler didn't finish")
+	}
+	if executions := call.Executions(); len(executions) != 1 || executions[0].App != "answer" {
+		t.Fatalf("unexpected executions %+v", executions)
+	}
+
+	if anonymous, err := goesltest.DialOutboundTLS(server.Addr(), certs.AnonymousClientConfig(), nil); err == nil {
+		anonymous.Close()
+		t.Fatal("expected call without certificate to be rejected")
+	}
+	if n := atomic.LoadInt32(&handled); n != 1 {
+		t.Fatalf("expected only verified call to be handled, got %d", n)
+	}
+}
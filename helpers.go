@@ -6,7 +6,11 @@
 
 package goesl
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"strings"
+)
 
 // ExecuteSet - Helper that you can use to execute SET application against active ESL session
 func (sc *SocketConnection) ExecuteSet(key string, value string, sync bool) error {
@@ -27,12 +31,31 @@ func (sc *SocketConnection) ExecuteHangup(uuid string, args string, sync bool) (
 	return sc.Execute("hangup", args, sync)
 }
 
-// Api - Helper designed to attach api in front of the command so that you do not need to write it
-func (sc *SocketConnection) Api(command string) error {
-	return sc.Send(fmt.Sprintf("api " + command))
+// Api - Helper designed to attach api in front of the command so that you do not need to write it.
+// Waits for api/response and returns it. -ERR response is returned along with ErrorUnsuccessfulReply.
+func (sc *SocketConnection) Api(ctx context.Context, command string) (*Message, error) {
+	return sc.request(ctx, "api "+command)
 }
 
-// BgApi - Helper designed to attach bgapi in front of the command so that you do not need to write it
-func (sc *SocketConnection) BgApi(command string) error {
-	return sc.Send(fmt.Sprintf("bgapi " + command))
+// BgApi - Helper designed to attach bgapi in front of the command so that you do not need to write it.
+// Returns Job that resolves when matching BACKGROUND_JOB event arrives, so connection must be subscribed to it.
+func (sc *SocketConnection) BgApi(ctx context.Context, command string) (*Job, error) {
+	if strings.ContainsAny(command, "\r\n") {
+		return nil, newErrorInvalidCommand(command)
+	}
+
+	job := sc.registerJob(newUUID())
+
+	reply, err := sc.sendCommand(ctx, []byte(fmt.Sprintf("bgapi %s\nJob-UUID: %s\n\n", command, job.UUID)), true)
+	if err != nil {
+		job.Cancel()
+		return nil, err
+	}
+
+	if _, err := sc.waitReply(ctx, reply); err != nil {
+		job.Cancel()
+		return nil, err
+	}
+
+	return job, nil
 }
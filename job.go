@@ -0,0 +1,113 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl
+
+import (
+	"context"
+	"sync"
+)
+
+// Job - Background api job started with BgApi. Resolves when BACKGROUND_JOB event with the same Job-UUID arrives
+type Job struct {
+	UUID string
+
+	conn   *SocketConnection
+	done   chan struct{}
+	once   sync.Once
+	result *Message
+	err    error
+}
+
+// Done - Will return channel that is closed once job is resolved, failed or cancelled
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Result - Will return BACKGROUND_JOB event or error. Valid only after Done is closed
+func (j *Job) Result() (*Message, error) {
+	return j.result, j.err
+}
+
+// Wait - Will wait for job result. If ctx finishes first the job is cancelled and ctx error returned
+func (j *Job) Wait(ctx context.Context) (*Message, error) {
+	select {
+	case <-j.done:
+		return j.result, j.err
+	case <-ctx.Done():
+		j.Cancel()
+		return nil, ctx.Err()
+	}
+}
+
+// Cancel - Will stop waiting for job result. Job itself keeps running on FreeSWITCH side
+func (j *Job) Cancel() {
+	j.conn.unregisterJob(j.UUID)
+	j.resolve(nil, newErrorJobCancelled(j.UUID))
+}
+
+func (j *Job) resolve(aMsg *Message, aErr error) {
+	j.once.Do(func() {
+		j.result = aMsg
+		j.err = aErr
+		close(j.done)
+	})
+}
+
+// registerJob starts tracking job before bgapi is sent, so event can't outrun it
+func (c *SocketConnection) registerJob(aUUID string) *Job {
+	job := &Job{
+		UUID: aUUID,
+		conn: c,
+		done: make(chan struct{}),
+	}
+
+	c.jobLock.Lock()
+	c.jobs[aUUID] = job
+	c.jobLock.Unlock()
+	c.wakeReader()
+
+	return job
+}
+
+func (c *SocketConnection) unregisterJob(aUUID string) {
+	c.jobLock.Lock()
+	delete(c.jobs, aUUID)
+	c.jobLock.Unlock()
+}
+
+// resolveJob completes job waiting for the message. Returns false if message is not its BACKGROUND_JOB event
+func (c *SocketConnection) resolveJob(aMsg *Message) bool {
+	if aMsg.GetHeader("Event-Name") != "BACKGROUND_JOB" {
+		return false
+	}
+
+	uuid := aMsg.GetHeader("Job-Uuid")
+
+	c.jobLock.Lock()
+	job, ok := c.jobs[uuid]
+	delete(c.jobs, uuid)
+	c.jobLock.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	job.resolve(aMsg, nil)
+	return true
+}
+
+// failJobs fails all tracked jobs once connection is gone
+func (c *SocketConnection) failJobs() {
+	c.jobLock.Lock()
+	jobs := c.jobs
+	c.jobs = make(map[string]*Job)
+	c.jobLock.Unlock()
+
+	for _, job := range jobs {
+		job.resolve(nil, c.closedError())
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PSyton/goesl"
+)
+
+func TestBgApiJobsResolveByJobUUID(t *testing.T) {
+	server, client := startClient(t, nil)
+	defer server.Close()
+	defer client.Close()
+
+	server.HandleAPI(func(command string) string {
+		return "+OK " + command
+	})
+
+	ctx, cancel := testContext()
+	defer cancel()
+
+	if err := client.Subscribe(ctx, goesl.EventFormatJSON, string(goesl.EventBackgroundJob)); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			command := fmt.Sprintf("originate user/%d &park", i)
+			job, err := client.BgApi(ctx, command)
+			if err != nil {
+				t.Errorf("%s: %v", command, err)
+				return
+			}
+
+			result, err := job.Wait(ctx)
+			if err != nil {
+				t.Errorf("%s: %v", command, err)
+				return
+			}
+			if got := result.GetReplyText(); got != "+OK "+command {
+				t.Errorf("%s: got result %q", command, got)
+			}
+			if got := result.GetHeader("Job-Uuid"); got != job.UUID {
+				t.Errorf("%s: got event of job %s, expected %s", command, got, job.UUID)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestBgApiJobWaitTimesOutAndCancels(t *testing.T) {
+	server, client := startClient(t, nil)
+	defer server.Close()
+	defer client.Close()
+
+	// not subscribed, BACKGROUND_JOB never arrives
+	ctx, cancel := testContext()
+	defer cancel()
+
+	job, err := client.BgApi(ctx, "status")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer waitCancel()
+
+	if _, err := job.Wait(waitCtx); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	<-job.Done()
+	var cancelled *goesl.ErrorJobCancelled
+	if _, err := job.Result(); !errors.As(err, &cancelled) {
+		t.Fatalf("expected cancelled job, got %v", err)
+	}
+}
+
+func TestBgApiInvalidCommand(t *testing.T) {
+	server, client := startClient(t, nil)
+	defer server.Close()
+	defer client.Close()
+
+	ctx, cancel := testContext()
+	defer cancel()
+
+	var invalid *goesl.ErrorInvalidCommand
+	if _, err := client.BgApi(ctx, "status\r\n\r\napi evil"); !errors.As(err, &invalid) {
+		t.Fatalf("expected ErrorInvalidCommand, got %v", err)
+	}
+}
+
+func TestBgApiRejectedCommand(t *testing.T) {
+	client, far, reader := startPipeClient(t, nil)
+	defer far.Close()
+	defer client.Close()
+
+	ctx, cancel := testContext()
+	defer cancel()
+
+	jobUUID := make(chan string, 1)
+	go func() {
+		reader.ReadString('\n')
+		header, _ := reader.ReadString('\n')
+		reader.ReadString('\n')
+		jobUUID <- strings.TrimSpace(strings.TrimPrefix(header, "Job-UUID:"))
+		io.WriteString(far, "Content-Type: command/reply\nReply-Text: -ERR no such command\n\n")
+	}()
+
+	var unsuccessful *goesl.ErrorUnsuccessfulReply
+	if _, err := client.BgApi(ctx, "unknown"); !errors.As(err, &unsuccessful) || unsuccessful.Reason != "no such command" {
+		t.Fatalf("expected ErrorUnsuccessfulReply, got %v", err)
+	}
+
+	// job is unregistered, so late BACKGROUND_JOB with its uuid is not consumed
+	body := "Event-Name: BACKGROUND_JOB\nJob-UUID: " + <-jobUUID + "\n\n"
+	go io.WriteString(far, fmt.Sprintf("Content-Type: text/event-plain\nContent-Length: %d\n\n%s", len(body), body))
+
+	select {
+	case msg, ok := <-client.Messages():
+		if !ok || msg.GetHeader("Event-Name") != string(goesl.EventBackgroundJob) {
+			t.Fatalf("unexpected message %v, connection error %v", msg, client.Err())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("BACKGROUND_JOB of rejected job wasn't delivered to Messages()")
+	}
+}
@@ -9,6 +9,7 @@ package goesl
 import (
 	"fmt"
 	"sort"
+	"strings"
 )
 
 // Message - Freeswitch Message that is received by GoESL. Message struct is here to help with parsing message
@@ -33,6 +34,27 @@ func (m *Message) GetHeader(key string) string {
 	return m.Headers[key]
 }
 
+// GetReplyText - Will return reply status of command/reply (Reply-Text), api/response or BACKGROUND_JOB (body)
+func (m *Message) GetReplyText() string {
+	if m.GetHeader("Content-Type") == "api/response" || m.GetHeader("Event-Name") == "BACKGROUND_JOB" {
+		return strings.TrimSpace(string(m.Body))
+	}
+	return m.GetHeader("Reply-Text")
+}
+
+// IsSuccessful - Will return false when reply carries -ERR status
+func (m *Message) IsSuccessful() bool {
+	return !strings.HasPrefix(m.GetReplyText(), "-ERR")
+}
+
+// replyError returns ErrorUnsuccessfulReply carrying the reason of -ERR reply, nil for successful one
+func (m *Message) replyError() error {
+	if m.IsSuccessful() {
+		return nil
+	}
+	return newErrorUnsuccessfulReply(strings.TrimSpace(strings.TrimPrefix(m.GetReplyText(), "-ERR")))
+}
+
 // Dump - Will return message prepared to be dumped out. It's like prettify message for output
 func (m *Message) Dump() (resp string) {
 	var keys []string
@@ -0,0 +1,242 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy - What happens to the message when Messages() buffer is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock - Reading from the connection waits until consumer takes the message.
+	// Reading doesn't wait while somebody waits for reply, job or application result, buffer grows instead
+	// by up to MaxEventBacklog messages. Connection is closed with ErrorEventOverflow beyond that
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest - The oldest buffered message is dropped to make room for the new one
+	OverflowDropOldest
+	// OverflowDropNewest - The new message is dropped
+	OverflowDropNewest
+	// OverflowDisconnect - Connection is closed with ErrorEventOverflow
+	OverflowDisconnect
+)
+
+// DefaultEventBufferSize is Messages() buffer size used by dropping and disconnecting policies when size is not set
+const DefaultEventBufferSize = 1024
+
+// MaxEventBacklog is how many messages OverflowBlock buffers beyond Messages() buffer size
+// while somebody waits for reply, job or application result
+const MaxEventBacklog = 1024
+
+// messageQueue buffers messages between reading goroutine and Messages()
+type messageQueue struct {
+	lock     sync.Mutex
+	items    []*Message
+	size     int
+	overflow OverflowPolicy
+	// no more messages will be added
+	finished bool
+
+	// signalled when message is added or queue is finished
+	ready chan struct{}
+	// signalled when message is taken or reading should stop waiting for room
+	room chan struct{}
+}
+
+func newMessageQueue() *messageQueue {
+	return &messageQueue{
+		ready: make(chan struct{}, 1),
+		room:  make(chan struct{}, 1),
+	}
+}
+
+func signal(aCh chan struct{}) {
+	select {
+	case aCh <- struct{}{}:
+	default:
+	}
+}
+
+func (q *messageQueue) len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return len(q.items)
+}
+
+func (q *messageQueue) finish() {
+	q.lock.Lock()
+	q.finished = true
+	q.lock.Unlock()
+
+	signal(q.ready)
+}
+
+// next takes the oldest message, waiting for it until queue is finished or aStop is closed
+func (q *messageQueue) next(aStop <-chan struct{}) (*Message, bool) {
+	for {
+		q.lock.Lock()
+		if len(q.items) > 0 {
+			msg := q.items[0]
+			q.items[0] = nil
+			q.items = q.items[1:]
+			q.lock.Unlock()
+
+			signal(q.room)
+			return msg, true
+		}
+		finished := q.finished
+		q.lock.Unlock()
+
+		if finished {
+			return nil, false
+		}
+
+		select {
+		case <-q.ready:
+		case <-aStop:
+			return nil, false
+		}
+	}
+}
+
+// setEventBuffer configures Messages() buffer. Must be called before reading starts
+func (c *SocketConnection) setEventBuffer(aSize int, aPolicy OverflowPolicy) {
+	if aSize <= 0 && aPolicy != OverflowBlock {
+		aSize = DefaultEventBufferSize
+	}
+	if aSize < 0 {
+		aSize = 0
+	}
+	c.queue.size = aSize
+	c.queue.overflow = aPolicy
+}
+
+// DroppedEvents - Will return number of messages that didn't make it to Messages() because its buffer was full
+func (c *SocketConnection) DroppedEvents() uint64 {
+	return atomic.LoadUint64(&c.dropped)
+}
+
+// BufferedEvents - Will return number of messages waiting in Messages() buffer
+func (c *SocketConnection) BufferedEvents() int {
+	return c.queue.len()
+}
+
+// waiting reports whether somebody waits for data that can come only after buffered messages
+func (c *SocketConnection) waiting() bool {
+	c.replyLock.Lock()
+	for _, r := range c.replies {
+		if r.result != nil {
+			c.replyLock.Unlock()
+			return true
+		}
+	}
+	c.replyLock.Unlock()
+
+	c.jobLock.Lock()
+	jobs := len(c.jobs)
+	c.jobLock.Unlock()
+
+	c.execLock.Lock()
+	executions := len(c.executions)
+	c.execLock.Unlock()
+
+	return jobs > 0 || executions > 0
+}
+
+// wakeReader lets reading blocked by full buffer continue, because somebody started waiting
+func (c *SocketConnection) wakeReader() {
+	signal(c.queue.room)
+}
+
+// deliver buffers message for Messages() according to overflow policy. Returns false when reading must stop
+func (c *SocketConnection) deliver(aMsg *Message) bool {
+	q := c.queue
+
+	capacity := q.size
+	if capacity == 0 {
+		// unbuffered: one message waits for the consumer while next one is read
+		capacity = 1
+	}
+
+	for {
+		q.lock.Lock()
+		if len(q.items) < capacity {
+			q.items = append(q.items, aMsg)
+			q.lock.Unlock()
+
+			signal(q.ready)
+			return true
+		}
+
+		switch q.overflow {
+		case OverflowBlock:
+			if !c.waiting() {
+				break
+			}
+			if len(q.items) >= capacity+MaxEventBacklog {
+				q.lock.Unlock()
+
+				atomic.AddUint64(&c.dropped, 1)
+				logger.Error(eEventOverflow, capacity+MaxEventBacklog)
+				return c.fatal(newErrorEventOverflow(capacity + MaxEventBacklog))
+			}
+			q.items = append(q.items, aMsg)
+			q.lock.Unlock()
+
+			signal(q.ready)
+			return true
+		case OverflowDropOldest:
+			q.items[0] = nil
+			q.items = append(q.items[1:], aMsg)
+			q.lock.Unlock()
+
+			atomic.AddUint64(&c.dropped, 1)
+			signal(q.ready)
+			return true
+		case OverflowDropNewest:
+			q.lock.Unlock()
+
+			atomic.AddUint64(&c.dropped, 1)
+			return true
+		case OverflowDisconnect:
+			q.lock.Unlock()
+
+			atomic.AddUint64(&c.dropped, 1)
+			logger.Error(eEventOverflow, q.size)
+			return c.fatal(newErrorEventOverflow(q.size))
+		}
+		q.lock.Unlock()
+
+		select {
+		case <-q.room:
+		case <-c.done:
+			return false
+		}
+	}
+}
+
+// pump passes buffered messages to Messages() and closes it once reading ends and buffer is drained,
+// or connection is closed with Close
+func (c *SocketConnection) pump() {
+	defer close(c.m)
+
+	for {
+		msg, ok := c.queue.next(c.stop)
+		if !ok {
+			return
+		}
+
+		select {
+		case c.m <- msg:
+		case <-c.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/PSyton/goesl"
+	"github.com/PSyton/goesl/goesltest"
+)
+
+const overflowEvents = 10
+
+// pushEvents sends numbered events nobody reads yet, then waits until client read all of them
+// with synchronous api. Returns api error
+func pushEvents(t *testing.T, server *goesltest.Server, client *goesl.Client) error {
+	t.Helper()
+
+	for i := 0; i < overflowEvents; i++ {
+		headers := map[string]string{"Event-Name": "HEARTBEAT", "Event-Sequence": strconv.Itoa(i)}
+		if err := server.PushEvent(goesl.EventFormatPlain, headers, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := testContext()
+	defer cancel()
+
+	_, err := client.Api(ctx, "status")
+	return err
+}
+
+// receivedSequences returns sequence numbers of events left for Messages()
+func receivedSequences(client *goesl.Client) []int {
+	var sequences []int
+	for {
+		select {
+		case msg, ok := <-client.Messages():
+			if !ok {
+				return sequences
+			}
+			seq, _ := strconv.Atoi(msg.GetHeader("Event-Sequence"))
+			sequences = append(sequences, seq)
+		case <-time.After(200 * time.Millisecond):
+			return sequences
+		}
+	}
+}
+
+func startOverflowClient(t *testing.T, policy goesl.OverflowPolicy) (*goesltest.Server, *goesl.Client) {
+	t.Helper()
+
+	server, client := startClient(t, func(opts *goesl.ConnectOptions) {
+		opts.EventBuffer = 3
+		opts.EventOverflow = policy
+	})
+	server.SetAPIResponse("status", "UP")
+	return server, client
+}
+
+func TestOverflowBlockKeepsEveryEvent(t *testing.T) {
+	server, client := startOverflowClient(t, goesl.OverflowBlock)
+	defer server.Close()
+	defer client.Close()
+
+	// reading waits for the consumer, but never while api waits for its reply
+	if err := pushEvents(t, server, client); err != nil {
+		t.Fatal(err)
+	}
+
+	sequences := receivedSequences(client)
+	if len(sequences) != overflowEvents {
+		t.Fatalf("expected %d events, got %v", overflowEvents, sequences)
+	}
+	for i, seq := range sequences {
+		if seq != i {
+			t.Fatalf("events out of order: %v", sequences)
+		}
+	}
+	if client.DroppedEvents() != 0 {
+		t.Fatalf("expected no dropped events, got %d", client.DroppedEvents())
+	}
+}
+
+func TestOverflowDropOldest(t *testing.T) {
+	server, client := startOverflowClient(t, goesl.OverflowDropOldest)
+	defer server.Close()
+	defer client.Close()
+
+	if err := pushEvents(t, server, client); err != nil {
+		t.Fatal(err)
+	}
+
+	sequences := receivedSequences(client)
+	dropped := client.DroppedEvents()
+	if dropped == 0 || len(sequences)+int(dropped) != overflowEvents {
+		t.Fatalf("expected received and dropped to add up to %d, got %v and %d", overflowEvents, sequences, dropped)
+	}
+	if last := sequences[len(sequences)-1]; last != overflowEvents-1 {
+		t.Fatalf("expected newest event to be kept, got %v", sequences)
+	}
+}
+
+func TestOverflowDropNewest(t *testing.T) {
+	server, client := startOverflowClient(t, goesl.OverflowDropNewest)
+	defer server.Close()
+	defer client.Close()
+
+	if err := pushEvents(t, server, client); err != nil {
+		t.Fatal(err)
+	}
+
+	sequences := receivedSequences(client)
+	dropped := client.DroppedEvents()
+	if dropped == 0 || len(sequences)+int(dropped) != overflowEvents {
+		t.Fatalf("expected received and dropped to add up to %d, got %v and %d", overflowEvents, sequences, dropped)
+	}
+	if sequences[0] != 0 {
+		t.Fatalf("expected oldest event to be kept, got %v", sequences)
+	}
+}
+
+func TestOverflowDisconnect(t *testing.T) {
+	server, client := startOverflowClient(t, goesl.OverflowDisconnect)
+	defer server.Close()
+	defer client.Close()
+
+	if err := pushEvents(t, server, client); !errors.Is(err, goesl.ErrConnectionClosed) {
+		t.Fatalf("expected api to fail with ErrConnectionClosed, got %v", err)
+	}
+
+	select {
+	case <-client.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection wasn't closed")
+	}
+	waitClosed(t, client.Messages())
+
+	var overflow *goesl.ErrorEventOverflow
+	if !errors.As(client.Err(), &overflow) || overflow.Size != 3 {
+		t.Fatalf("expected ErrorEventOverflow, got %v", client.Err())
+	}
+}
+
+func TestOverflowBlockBacklogIsBounded(t *testing.T) {
+	server, client := startOverflowClient(t, goesl.OverflowBlock)
+	defer server.Close()
+	defer client.Close()
+
+	ctx, cancel := testContext()
+	defer cancel()
+
+	// not subscribed to BACKGROUND_JOB, so the job keeps waiting and reading doesn't block
+	job, err := client.BgApi(ctx, "status")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3+goesl.MaxEventBacklog+100; i++ {
+		if err := server.PushEvent(goesl.EventFormatPlain, map[string]string{"Event-Name": "HEARTBEAT"}, ""); err != nil {
+			break
+		}
+	}
+
+	select {
+	case <-client.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("connection wasn't closed, %d events buffered", client.BufferedEvents())
+	}
+
+	var overflow *goesl.ErrorEventOverflow
+	if !errors.As(client.Err(), &overflow) || overflow.Size != 3+goesl.MaxEventBacklog {
+		t.Fatalf("expected ErrorEventOverflow, got %v", client.Err())
+	}
+	if buffered := client.BufferedEvents(); buffered > 3+goesl.MaxEventBacklog {
+		t.Fatalf("expected at most %d buffered events, got %d", 3+goesl.MaxEventBacklog, buffered)
+	}
+	if _, err := job.Wait(ctx); !errors.Is(err, goesl.ErrConnectionClosed) {
+		t.Fatalf("expected job to fail with ErrConnectionClosed, got %v", err)
+	}
+}
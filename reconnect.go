@@ -0,0 +1,279 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ConnectionState - State of ReconnectingClient connection
+type ConnectionState int
+
+// Connection states reported by ReconnectingClient
+const (
+	StateConnecting ConnectionState = iota
+	StateConnected
+	StateDisconnected
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateDisconnected:
+		return "disconnected"
+	}
+	return "unknown"
+}
+
+// Default reconnect backoff settings
+const (
+	DefaultInitialBackoff = 500 * time.Millisecond
+	DefaultMaxBackoff     = 30 * time.Second
+	DefaultBackoffFactor  = 2.0
+	DefaultBackoffJitter  = 0.2
+)
+
+// ReconnectOptions represent options of reconnecting client
+type ReconnectOptions struct {
+	ConnectOptions
+
+	// Delay before first reconnect attempt. Grows by BackoffFactor up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	BackoffFactor  float64
+	// Fraction of delay randomly added or subtracted, up to 1. DefaultBackoffJitter is used when zero,
+	// negative disables jitter
+	BackoffJitter float64
+
+	// Called from reconnect goroutine on every state change. err is the reason of failure if any.
+	// For StateConnected it's the first subscription FreeSWITCH rejected on restore, such subscriptions are dropped
+	OnStateChange func(state ConnectionState, err error)
+}
+
+// ReconnectingClient - Inbound client that keeps connection to freeswitch alive.
+// Event subscriptions and filters issued through Client() are restored after every reconnect.
+type ReconnectingClient struct {
+	opts ReconnectOptions
+
+	lock          sync.Mutex
+	client        *Client
+	state         ConnectionState
+	subscriptions Subscriptions
+
+	m       chan *Message
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stop    chan struct{}
+	stopped sync.Once
+	done    chan struct{}
+}
+
+// NewReconnectingClient - Will start connecting in background. Use OnStateChange or State to know when connection is ready
+func NewReconnectingClient(aOpts ReconnectOptions) *ReconnectingClient {
+	if aOpts.InitialBackoff <= 0 {
+		aOpts.InitialBackoff = DefaultInitialBackoff
+	}
+	if aOpts.MaxBackoff <= 0 {
+		aOpts.MaxBackoff = DefaultMaxBackoff
+	}
+	if aOpts.BackoffFactor < 1 {
+		aOpts.BackoffFactor = DefaultBackoffFactor
+	}
+	if aOpts.BackoffJitter == 0 {
+		aOpts.BackoffJitter = DefaultBackoffJitter
+	}
+	if aOpts.BackoffJitter < 0 {
+		aOpts.BackoffJitter = 0
+	}
+	if aOpts.BackoffJitter > 1 {
+		aOpts.BackoffJitter = 1
+	}
+
+	r := &ReconnectingClient{
+		opts:  aOpts,
+		state: StateDisconnected,
+		m:     make(chan *Message),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+
+	go r.run()
+	return r
+}
+
+// Client - Will return current client or nil when not connected
+func (r *ReconnectingClient) Client() *Client {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.client
+}
+
+// State - Will return current connection state
+func (r *ReconnectingClient) State() ConnectionState {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.state
+}
+
+// Messages - Will return messages of all connections. Closed after Close
+func (r *ReconnectingClient) Messages() chan *Message {
+	return r.m
+}
+
+// Close - Will stop reconnecting and close current connection
+func (r *ReconnectingClient) Close() {
+	r.stopped.Do(func() {
+		close(r.stop)
+		r.cancel()
+	})
+	<-r.done
+}
+
+func (r *ReconnectingClient) setState(aState ConnectionState, aErr error) {
+	r.lock.Lock()
+	r.state = aState
+	r.lock.Unlock()
+
+	logger.Debug("Reconnecting client state: %s", aState)
+
+	if r.opts.OnStateChange != nil {
+		r.opts.OnStateChange(aState, aErr)
+	}
+}
+
+func (r *ReconnectingClient) isStopped() bool {
+	select {
+	case <-r.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// backoff returns jittered delay before reconnect attempt (starting from 0)
+func (r *ReconnectingClient) backoff(aAttempt int) time.Duration {
+	delay := float64(r.opts.InitialBackoff)
+	for i := 0; i < aAttempt && delay < float64(r.opts.MaxBackoff); i++ {
+		delay *= r.opts.BackoffFactor
+	}
+	if delay > float64(r.opts.MaxBackoff) {
+		delay = float64(r.opts.MaxBackoff)
+	}
+
+	delay += delay * r.opts.BackoffJitter * (rand.Float64()*2 - 1)
+	return time.Duration(delay)
+}
+
+func (r *ReconnectingClient) run() {
+	defer close(r.done)
+	defer close(r.m)
+
+	attempt := 0
+	for {
+		r.setState(StateConnecting, nil)
+
+		client, err := DialContext(r.ctx, r.opts.ConnectOptions)
+		if err == nil {
+			var restored bool
+			restored, err = r.serve(client)
+			if restored {
+				attempt = 0
+			}
+		} else {
+			logger.Error("Reconnecting client failed to connect: %s", err)
+		}
+
+		r.setState(StateDisconnected, err)
+
+		if r.isStopped() {
+			return
+		}
+
+		select {
+		case <-time.After(r.backoff(attempt)):
+			attempt++
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// serve restores subscriptions and forwards messages until connection ends.
+// Returns true if connection was fully restored and the reason connection ended.
+func (r *ReconnectingClient) serve(aClient *Client) (bool, error) {
+	r.lock.Lock()
+	r.client = aClient
+	subscriptions := r.subscriptions.clone()
+	r.lock.Unlock()
+
+	ended := make(chan error, 1)
+	go func() {
+		ended <- r.forward(aClient)
+	}()
+
+	rejected, err := r.restore(aClient, subscriptions)
+	restored := err == nil
+	if restored {
+		r.setState(StateConnected, rejected)
+	} else {
+		logger.Error("Reconnecting client failed to restore subscriptions: %s", err)
+		aClient.Close()
+	}
+
+	select {
+	case readErr := <-ended:
+		if restored {
+			err = readErr
+		}
+	case <-r.stop:
+		aClient.Close()
+		<-ended
+	}
+
+	r.lock.Lock()
+	if restored {
+		r.subscriptions = aClient.Subscriptions()
+	}
+	r.client = nil
+	r.lock.Unlock()
+
+	return restored, err
+}
+
+// forward passes messages of the client until connection ends and returns the reason
+func (r *ReconnectingClient) forward(aClient *Client) error {
+	for msg := range aClient.Messages() {
+		select {
+		case r.m <- msg:
+		case <-r.stop:
+			// nobody reads anymore, drop it
+		}
+	}
+	return aClient.Err()
+}
+
+// restore re-applies subscriptions of the previous connection. Returns first rejected subscription
+// and error that prevented restoring
+func (r *ReconnectingClient) restore(aClient *Client, aSubs Subscriptions) (rejected error, err error) {
+	ctx := r.ctx
+	if r.opts.DialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.opts.DialTimeout)
+		defer cancel()
+	}
+
+	return aClient.restoreSubscriptions(ctx, aSubs)
+}
@@ -0,0 +1,281 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl_test
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PSyton/goesl"
+	"github.com/PSyton/goesl/goesltest"
+)
+
+// stateRecorder collects states reported by OnStateChange
+type stateRecorder struct {
+	lock   sync.Mutex
+	states []goesl.ConnectionState
+	errs   []error
+	times  []time.Time
+}
+
+func (r *stateRecorder) record(state goesl.ConnectionState, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.states = append(r.states, state)
+	r.errs = append(r.errs, err)
+	r.times = append(r.times, time.Now())
+}
+
+// wait fails unless state is reported count times in time. Returns error reported with the last one
+func (r *stateRecorder) wait(t *testing.T, state goesl.ConnectionState, count int) error {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		r.lock.Lock()
+		seen := 0
+		var err error
+		for i, s := range r.states {
+			if s == state {
+				seen++
+				err = r.errs[i]
+			}
+		}
+		r.lock.Unlock()
+
+		if seen >= count {
+			return err
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("state %s wasn't reported %d times, got %v", state, count, r.snapshot())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func (r *stateRecorder) snapshot() []goesl.ConnectionState {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return append([]goesl.ConnectionState(nil), r.states...)
+}
+
+// closedAddress returns ConnectOptions pointing to port nobody listens on
+func closedAddress(t *testing.T) goesl.ConnectOptions {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().(*net.TCPAddr)
+	l.Close()
+
+	return goesl.ConnectOptions{Host: "127.0.0.1", Port: uint(addr.Port), Password: "ClueCon"}
+}
+
+func TestReconnectRestoresSubscriptions(t *testing.T) {
+	server, err := goesltest.NewServer("ClueCon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	var states stateRecorder
+	r := goesl.NewReconnectingClient(goesl.ReconnectOptions{
+		ConnectOptions: server.ConnectOptions(),
+		InitialBackoff: 10 * time.Millisecond,
+		OnStateChange:  states.record,
+	})
+	defer r.Close()
+
+	states.wait(t, goesl.StateConnected, 1)
+
+	ctx, cancel := testContext()
+	defer cancel()
+
+	client := r.Client()
+	if err := client.Subscribe(ctx, goesl.EventFormatJSON, "CHANNEL_ANSWER"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.AddFilter(ctx, "Unique-ID", "uuid-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	server.DropConnections()
+	if err := states.wait(t, goesl.StateConnected, 2); err != nil {
+		t.Fatalf("unexpected restore error %v", err)
+	}
+
+	expected := []goesl.ConnectionState{
+		goesl.StateConnecting, goesl.StateConnected, goesl.StateDisconnected, goesl.StateConnecting, goesl.StateConnected,
+	}
+	if got := states.snapshot(); len(got) != len(expected) {
+		t.Fatalf("expected states %v, got %v", expected, got)
+	} else {
+		for i := range expected {
+			if got[i] != expected[i] {
+				t.Fatalf("expected states %v, got %v", expected, got)
+			}
+		}
+	}
+
+	commands := server.Commands()
+	restored := strings.Join(commands[len(commands)-2:], ", ")
+	if restored != "event json CHANNEL_ANSWER, filter Unique-ID uuid-1" {
+		t.Fatalf("expected subscriptions restored, got %v", commands)
+	}
+
+	if err := server.PushEvent(goesl.EventFormatJSON, map[string]string{"Event-Name": "CHANNEL_ANSWER"}, ""); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-r.Messages():
+		if msg.GetHeader("Event-Name") != "CHANNEL_ANSWER" {
+			t.Fatalf("unexpected message %s", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("event of new connection wasn't forwarded")
+	}
+}
+
+func TestReconnectDropsRejectedSubscriptions(t *testing.T) {
+	server, err := goesltest.NewServer("ClueCon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	var lock sync.Mutex
+	rejectFilters := false
+	server.HandleCommand(func(command string, headers map[string]string) string {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if rejectFilters && strings.HasPrefix(command, "filter") {
+			return "-ERR filter not allowed"
+		}
+		return "+OK " + command
+	})
+
+	var states stateRecorder
+	r := goesl.NewReconnectingClient(goesl.ReconnectOptions{
+		ConnectOptions: server.ConnectOptions(),
+		InitialBackoff: 10 * time.Millisecond,
+		OnStateChange:  states.record,
+	})
+	defer r.Close()
+
+	states.wait(t, goesl.StateConnected, 1)
+
+	ctx, cancel := testContext()
+	defer cancel()
+
+	if err := r.Client().Subscribe(ctx, goesl.EventFormatPlain, "HEARTBEAT"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Client().AddFilter(ctx, "Unique-ID", "uuid-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	lock.Lock()
+	rejectFilters = true
+	lock.Unlock()
+	server.DropConnections()
+
+	err = states.wait(t, goesl.StateConnected, 2)
+	var unsuccessful *goesl.ErrorUnsuccessfulReply
+	if !errors.As(err, &unsuccessful) {
+		t.Fatalf("expected rejected filter to be reported, got %v", err)
+	}
+
+	subs := r.Client().Subscriptions()
+	if len(subs.Events) != 1 || subs.Events[0] != "HEARTBEAT" || len(subs.Filters) != 0 {
+		t.Fatalf("expected events kept and filter dropped, got %+v", subs)
+	}
+
+	// dropped filter is not restored anymore
+	server.DropConnections()
+	if err := states.wait(t, goesl.StateConnected, 3); err != nil {
+		t.Fatalf("expected clean restore, got %v", err)
+	}
+}
+
+func TestReconnectBackoff(t *testing.T) {
+	var states stateRecorder
+	r := goesl.NewReconnectingClient(goesl.ReconnectOptions{
+		ConnectOptions: closedAddress(t),
+		InitialBackoff: 20 * time.Millisecond,
+		MaxBackoff:     80 * time.Millisecond,
+		BackoffFactor:  2,
+		BackoffJitter:  -1,
+		OnStateChange:  states.record,
+	})
+
+	states.wait(t, goesl.StateConnecting, 5)
+	r.Close()
+
+	states.lock.Lock()
+	var attempts []time.Time
+	for i, s := range states.states {
+		if s == goesl.StateConnecting {
+			attempts = append(attempts, states.times[i])
+		}
+	}
+	states.lock.Unlock()
+
+	for i, expected := range []time.Duration{20, 40, 80, 80} {
+		expected *= time.Millisecond
+		if gap := attempts[i+1].Sub(attempts[i]); gap < expected || gap > expected+time.Second {
+			t.Errorf("attempt %d: expected delay of %s, got %s", i+1, expected, gap)
+		}
+	}
+	if state := r.State(); state != goesl.StateDisconnected {
+		t.Fatalf("expected disconnected after Close, got %s", state)
+	}
+}
+
+func TestReconnectJitter(t *testing.T) {
+	opts := goesl.ReconnectOptions{
+		ConnectOptions: closedAddress(t),
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+	}
+
+	r := goesl.NewReconnectingClient(opts)
+	defer r.Close()
+
+	// zero jitter means default one
+	low, high := time.Duration(1<<62), time.Duration(0)
+	for i := 0; i < 100; i++ {
+		delay := r.Backoff(0)
+		if delay < low {
+			low = delay
+		}
+		if delay > high {
+			high = delay
+		}
+	}
+	if low == high || low < 80*time.Millisecond || high > 120*time.Millisecond {
+		t.Fatalf("expected delays jittered by %v, got %s..%s", goesl.DefaultBackoffJitter, low, high)
+	}
+
+	opts.BackoffJitter = -1
+	disabled := goesl.NewReconnectingClient(opts)
+	defer disabled.Close()
+
+	for i := 0; i < 10; i++ {
+		if delay := disabled.Backoff(1); delay != 200*time.Millisecond {
+			t.Fatalf("expected no jitter, got %s", delay)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// FrameDirection - Direction of recorded frame
+type FrameDirection string
+
+// Frame directions
+const (
+	FrameIn  FrameDirection = "in"
+	FrameOut FrameDirection = "out"
+)
+
+// Frame - Single chunk of bytes read from or written to the connection. Stored one JSON object per line
+type Frame struct {
+	Time      time.Time      `json:"time"`
+	Direction FrameDirection `json:"dir"`
+	Data      []byte         `json:"data"`
+}
+
+// recordingConn captures every read and write of the wrapped connection
+type recordingConn struct {
+	net.Conn
+
+	lock    sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewRecordingConn - Will wrap connection so every read and write is appended to w as Frame
+func NewRecordingConn(conn net.Conn, w io.Writer) net.Conn {
+	return &recordingConn{
+		Conn:    conn,
+		encoder: json.NewEncoder(w),
+	}
+}
+
+func (r *recordingConn) record(aDir FrameDirection, aData []byte) {
+	if len(aData) == 0 {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	frame := Frame{
+		Time:      time.Now(),
+		Direction: aDir,
+		Data:      append([]byte(nil), aData...),
+	}
+	if err := r.encoder.Encode(&frame); err != nil {
+		logger.Error("Can't record frame: %s", err)
+	}
+}
+
+func (r *recordingConn) Read(b []byte) (int, error) {
+	n, err := r.Conn.Read(b)
+	r.record(FrameIn, b[:n])
+	return n, err
+}
+
+func (r *recordingConn) Write(b []byte) (int, error) {
+	n, err := r.Conn.Write(b)
+	r.record(FrameOut, b[:n])
+	return n, err
+}
+
+// underlying gives access to wrapped connection, e.g. for keepalive setup
+func (r *recordingConn) underlying() net.Conn {
+	return r.Conn
+}
+
+// ReadFrames - Will read all frames recorded with NewRecordingConn
+func ReadFrames(r io.Reader) ([]Frame, error) {
+	var frames []Frame
+
+	decoder := json.NewDecoder(bufio.NewReader(r))
+	for {
+		var frame Frame
+		err := decoder.Decode(&frame)
+		if err == io.EOF {
+			return frames, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, frame)
+	}
+}
+
+type replayAddr struct{}
+
+func (replayAddr) Network() string { return "replay" }
+func (replayAddr) String() string  { return "replay" }
+
+// replayConn returns recorded incoming frames and discards everything written
+type replayConn struct {
+	lock   sync.Mutex
+	in     *bytes.Reader
+	closed bool
+}
+
+// NewReplayConn - Will return connection that reads incoming frames of recorded session in order.
+// Writes are discarded and deadlines are ignored, so replay is deterministic.
+func NewReplayConn(r io.Reader) (net.Conn, error) {
+	frames, err := ReadFrames(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var in bytes.Buffer
+	for _, frame := range frames {
+		if frame.Direction == FrameIn {
+			in.Write(frame.Data)
+		}
+	}
+
+	return &replayConn{in: bytes.NewReader(in.Bytes())}, nil
+}
+
+func (r *replayConn) Read(b []byte) (int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closed {
+		return 0, io.EOF
+	}
+	return r.in.Read(b)
+}
+
+func (r *replayConn) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (r *replayConn) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.closed = true
+	return nil
+}
+
+func (r *replayConn) LocalAddr() net.Addr                { return replayAddr{} }
+func (r *replayConn) RemoteAddr() net.Addr               { return replayAddr{} }
+func (r *replayConn) SetDeadline(t time.Time) error      { return nil }
+func (r *replayConn) SetReadDeadline(t time.Time) error  { return nil }
+func (r *replayConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// NewReplayConnection - Will feed recorded session through the regular message parsing.
+// Recorded messages come from Messages() in order, it's closed at the end of the recording.
+func NewReplayConnection(r io.Reader) (*SocketConnection, error) {
+	conn, err := NewReplayConn(r)
+	if err != nil {
+		return nil, err
+	}
+
+	c := newConnection(conn)
+	go c.handle()
+	return c, nil
+}
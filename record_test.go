@@ -0,0 +1,133 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PSyton/goesl"
+)
+
+// describe returns short description of message to compare sessions
+func describe(msg *goesl.Message) string {
+	if name := msg.GetHeader("Event-Name"); name != "" {
+		return "event " + name + " " + msg.GetHeader("Event-Sequence")
+	}
+	switch ct := msg.GetHeader("Content-Type"); ct {
+	case "command/reply":
+		return "reply " + msg.GetReplyText()
+	case "api/response":
+		return "api " + string(msg.Body)
+	default:
+		return ct
+	}
+}
+
+// collect returns descriptions of all messages until Messages() is closed
+func collect(t *testing.T, messages chan *goesl.Message) []string {
+	t.Helper()
+
+	var result []string
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				return result
+			}
+			result = append(result, describe(msg))
+		case <-timeout:
+			t.Fatalf("messages channel wasn't closed, got %v", result)
+		}
+	}
+}
+
+func TestRecordAndReplaySession(t *testing.T) {
+	var recording bytes.Buffer
+	server, client := startClient(t, func(opts *goesl.ConnectOptions) {
+		opts.Recorder = &recording
+	})
+	defer server.Close()
+	defer client.Close()
+	server.SetAPIResponse("status", "UP")
+
+	ctx, cancel := testContext()
+	defer cancel()
+
+	if err := client.Subscribe(ctx, goesl.EventFormatPlain, "HEARTBEAT"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Api(ctx, "status"); err != nil {
+		t.Fatal(err)
+	}
+	for _, seq := range []string{"1", "2"} {
+		if err := server.PushEvent(goesl.EventFormatPlain, map[string]string{"Event-Name": "HEARTBEAT", "Event-Sequence": seq}, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	server.Disconnect()
+
+	live := collect(t, client.Messages())
+	expected := []string{"event HEARTBEAT 1", "event HEARTBEAT 2", "text/disconnect-notice"}
+	if strings.Join(live, ", ") != strings.Join(expected, ", ") {
+		t.Fatalf("expected live session %v, got %v", expected, live)
+	}
+
+	frames, err := goesl.ReadFrames(bytes.NewReader(recording.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) == 0 || frames[0].Direction != goesl.FrameIn || !bytes.Contains(frames[0].Data, []byte("auth/request")) {
+		t.Fatalf("expected session to start with auth request, got %+v", frames)
+	}
+	var written []string
+	for _, f := range frames {
+		if f.Direction == goesl.FrameOut {
+			written = append(written, strings.TrimSpace(string(f.Data)))
+		}
+	}
+	if strings.Join(written, ", ") != "auth ClueCon, event plain HEARTBEAT, api status" {
+		t.Fatalf("unexpected written frames %q", written)
+	}
+
+	conn, err := goesl.NewReplayConn(bytes.NewReader(recording.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exit := []byte("exit\r\n\r\n")
+	if n, err := conn.Write(exit); err != nil || n != len(exit) {
+		t.Fatalf("expected write to be discarded, got %d, %v", n, err)
+	}
+	var incoming []byte
+	for _, f := range frames {
+		if f.Direction == goesl.FrameIn {
+			incoming = append(incoming, f.Data...)
+		}
+	}
+	if read, _ := ioutil.ReadAll(conn); !bytes.Equal(read, incoming) {
+		t.Fatalf("expected replay of incoming frames, got %q", read)
+	}
+	conn.Close()
+
+	replay, err := goesl.NewReplayConnection(bytes.NewReader(recording.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replay.Close()
+
+	// nothing waits for replies on replay, so they come from Messages() along with events.
+	// auth/request is consumed silently, reply to auth is delivered
+	replayed := collect(t, replay.Messages())
+	expected = append([]string{"reply +OK accepted", "reply +OK event listener enabled plain", "api UP"}, expected...)
+	if strings.Join(replayed, ", ") != strings.Join(expected, ", ") {
+		t.Fatalf("expected replayed session %v, got %v", expected, replayed)
+	}
+}
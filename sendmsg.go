@@ -0,0 +1,174 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+)
+
+// CallCommand - Value of sendmsg call-command header
+type CallCommand string
+
+// Supported call commands
+const (
+	CallCommandExecute CallCommand = "execute"
+	CallCommandHangup  CallCommand = "hangup"
+	CallCommandUnicast CallCommand = "unicast"
+	CallCommandNoMedia CallCommand = "nomedia"
+	CallCommandXferExt CallCommand = "xferext"
+)
+
+// SendMsgCommand - Typed sendmsg builder. Headers are written in the order they were added
+type SendMsgCommand struct {
+	uuid    string
+	headers [][2]string
+	body    string
+}
+
+// NewSendMsg - Will start sendmsg with call-command
+func NewSendMsg(command CallCommand) *SendMsgCommand {
+	return (&SendMsgCommand{}).Header("call-command", string(command))
+}
+
+// NewExecute - Will start sendmsg executing application. Multi-line args are sent as text/plain body
+func NewExecute(app, args string) *SendMsgCommand {
+	m := NewSendMsg(CallCommandExecute).Header("execute-app-name", app)
+
+	if strings.ContainsAny(args, "\r\n") {
+		m.body = args
+	} else {
+		m.Header("execute-app-arg", args)
+	}
+	return m
+}
+
+// NewHangup - Will start sendmsg hanging up the channel. Empty cause means NORMAL_CLEARING
+func NewHangup(cause string) *SendMsgCommand {
+	return NewSendMsg(CallCommandHangup).Header("hangup-cause", cause)
+}
+
+// NewUnicast - Will start sendmsg streaming channel media to remote address. transport is tcp or udp
+func NewUnicast(localIP string, localPort int, remoteIP string, remotePort int, transport string) *SendMsgCommand {
+	return NewSendMsg(CallCommandUnicast).
+		Header("local-ip", localIP).
+		Header("local-port", strconv.Itoa(localPort)).
+		Header("remote-ip", remoteIP).
+		Header("remote-port", strconv.Itoa(remotePort)).
+		Header("transport", transport)
+}
+
+// NewNoMedia - Will start sendmsg taking channel off media path
+func NewNoMedia(nomediaUUID string) *SendMsgCommand {
+	return NewSendMsg(CallCommandNoMedia).Header("nomedia-uuid", nomediaUUID)
+}
+
+// NewXferExt - Will start sendmsg transferring channel to inline extension. Add steps with Application
+func NewXferExt() *SendMsgCommand {
+	return NewSendMsg(CallCommandXferExt)
+}
+
+// UUID - Will address sendmsg to channel uuid. Outbound connections may omit it
+func (m *SendMsgCommand) UUID(uuid string) *SendMsgCommand {
+	m.uuid = uuid
+	return m
+}
+
+// Header - Will add raw header. Empty values are skipped
+func (m *SendMsgCommand) Header(key, value string) *SendMsgCommand {
+	if value != "" {
+		m.headers = append(m.headers, [2]string{key, value})
+	}
+	return m
+}
+
+// Application - Will add xferext application step
+func (m *SendMsgCommand) Application(app, args string) *SendMsgCommand {
+	return m.Header("application", strings.TrimSpace(app+" "+args))
+}
+
+// Loops - Will repeat application n times
+func (m *SendMsgCommand) Loops(n int) *SendMsgCommand {
+	if n > 1 {
+		m.Header("loops", strconv.Itoa(n))
+	}
+	return m
+}
+
+// EventLock - Will make FreeSWITCH execute applications of the channel one by one
+func (m *SendMsgCommand) EventLock(lock bool) *SendMsgCommand {
+	return m.Header("event-lock", strconv.FormatBool(lock))
+}
+
+// EventUUID - Will tag application with uuid reported as Application-UUID in its events
+func (m *SendMsgCommand) EventUUID(uuid string) *SendMsgCommand {
+	return m.Header("event-uuid", uuid)
+}
+
+// HoldBleg - Will keep b-leg on hold while application runs
+func (m *SendMsgCommand) HoldBleg(hold bool) *SendMsgCommand {
+	if hold {
+		m.Header("hold-bleg", "true")
+	}
+	return m
+}
+
+// Bytes - Will serialize sendmsg, adding content-type and content-length for body
+func (m *SendMsgCommand) Bytes() ([]byte, error) {
+	if strings.ContainsAny(m.uuid, "\r\n") {
+		return nil, newErrorInvalidCommand(m.uuid)
+	}
+
+	b := bytes.NewBufferString("sendmsg")
+	if m.uuid != "" {
+		b.WriteString(" " + m.uuid)
+	}
+	b.WriteString("\n")
+
+	for _, h := range m.headers {
+		if strings.ContainsAny(h[0], "\r\n:") || strings.ContainsAny(h[1], "\r\n") {
+			return nil, newErrorInvalidCommand(h[0] + ": " + h[1])
+		}
+		b.WriteString(h[0] + ": " + h[1] + "\n")
+	}
+
+	if m.body != "" {
+		b.WriteString("content-type: text/plain\n")
+		b.WriteString("content-length: " + strconv.Itoa(len(m.body)) + "\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(m.body)
+
+	return b.Bytes(), nil
+}
+
+// String - Will return serialized sendmsg or error text
+func (m *SendMsgCommand) String() string {
+	data, err := m.Bytes()
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
+
+// SendMsgCommand - Will send typed sendmsg and wait for its reply
+func (c *SocketConnection) SendMsgCommand(ctx context.Context, msg *SendMsgCommand) (*Message, error) {
+	data, err := msg.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := c.sendCommand(ctx, data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.waitReply(ctx, reply)
+}
@@ -0,0 +1,133 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/PSyton/goesl"
+)
+
+func TestSendMsgBuilder(t *testing.T) {
+	tests := []struct {
+		name     string
+		msg      *goesl.SendMsgCommand
+		expected string
+	}{
+		{
+			name:     "execute keeps header order",
+			msg:      goesl.NewExecute("playback", "/tmp/a.wav").EventLock(true).Loops(2).EventUUID("app-1").UUID("uuid-1"),
+			expected: "sendmsg uuid-1\ncall-command: execute\nexecute-app-name: playback\nexecute-app-arg: /tmp/a.wav\nevent-lock: true\nloops: 2\nevent-uuid: app-1\n\n",
+		},
+		{
+			name:     "multi-line args go to body",
+			msg:      goesl.NewExecute("set", "a=1\nb=2"),
+			expected: "sendmsg\ncall-command: execute\nexecute-app-name: set\ncontent-type: text/plain\ncontent-length: 7\n\na=1\nb=2",
+		},
+		{
+			name:     "empty values are skipped",
+			msg:      goesl.NewHangup("").Loops(1).HoldBleg(false),
+			expected: "sendmsg\ncall-command: hangup\n\n",
+		},
+		{
+			name:     "xferext steps",
+			msg:      goesl.NewXferExt().Application("answer", "").Application("playback", "x.wav"),
+			expected: "sendmsg\ncall-command: xferext\napplication: answer\napplication: playback x.wav\n\n",
+		},
+		{
+			name:     "unicast",
+			msg:      goesl.NewUnicast("127.0.0.1", 8025, "10.0.0.1", 8026, "udp"),
+			expected: "sendmsg\ncall-command: unicast\nlocal-ip: 127.0.0.1\nlocal-port: 8025\nremote-ip: 10.0.0.1\nremote-port: 8026\ntransport: udp\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		data, err := test.msg.Bytes()
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if string(data) != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, data)
+		}
+	}
+}
+
+func TestSendMsgBuilderRejectsInjection(t *testing.T) {
+	tests := map[string]*goesl.SendMsgCommand{
+		"colon in header name":   goesl.NewSendMsg(goesl.CallCommandExecute).Header("bad:name", "value"),
+		"newline in header name": goesl.NewSendMsg(goesl.CallCommandExecute).Header("bad\nname", "value"),
+		"newline in value":       goesl.NewSendMsg(goesl.CallCommandExecute).Header("name", "value\n\napi evil"),
+		"carriage return":        goesl.NewHangup("NORMAL_CLEARING\r"),
+		"newline in uuid":        goesl.NewHangup("").UUID("uuid\n\napi evil"),
+	}
+
+	for name, msg := range tests {
+		var invalid *goesl.ErrorInvalidCommand
+		if _, err := msg.Bytes(); !errors.As(err, &invalid) {
+			t.Errorf("%s: expected ErrorInvalidCommand, got %v", name, err)
+		}
+	}
+}
+
+func TestLegacySendMsg(t *testing.T) {
+	client, far, reader := startPipeClient(t, nil)
+	defer far.Close()
+	defer client.Close()
+
+	tests := []struct {
+		name     string
+		msg      map[string]string
+		uuid     string
+		data     string
+		expected string
+	}{
+		{
+			name:     "headers are sorted",
+			msg:      map[string]string{"call-command": "execute", "execute-app-name": "playback", "execute-app-arg": "x.wav"},
+			uuid:     "uuid-1",
+			expected: "sendmsg uuid-1\ncall-command: execute\nexecute-app-arg: x.wav\nexecute-app-name: playback\n\n",
+		},
+		{
+			name:     "data gets content-length",
+			msg:      map[string]string{"call-command": "execute", "execute-app-name": "set", "content-type": "text/plain"},
+			data:     "a=1\nb=2",
+			expected: "sendmsg\ncall-command: execute\ncontent-type: text/plain\nexecute-app-name: set\ncontent-length: 7\n\na=1\nb=2",
+		},
+		{
+			name:     "content-length of caller is kept",
+			msg:      map[string]string{"call-command": "execute", "Content-Length": "3"},
+			data:     "a=1",
+			expected: "sendmsg\nContent-Length: 3\ncall-command: execute\n\na=1",
+		},
+	}
+
+	for _, test := range tests {
+		sent := make(chan error, 1)
+		go func() {
+			sent <- client.SendMsg(test.msg, test.uuid, test.data)
+		}()
+
+		written := make([]byte, len(test.expected))
+		if _, err := io.ReadFull(reader, written); err != nil {
+			t.Fatal(err)
+		}
+		if err := <-sent; err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if string(written) != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, written)
+		}
+	}
+
+	var invalid *goesl.ErrorInvalidCommand
+	if err := client.SendMsg(map[string]string{"bad:name": "value"}, "", ""); !errors.As(err, &invalid) {
+		t.Fatalf("expected ErrorInvalidCommand, got %v", err)
+	}
+}
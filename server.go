@@ -7,7 +7,13 @@
 package goesl
 
 import (
+	"context"
+	"crypto/tls"
+	"io"
 	"net"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type (
@@ -18,6 +24,18 @@ type (
 // ESLConnection wrapper for incoming connection
 type ESLConnection struct {
 	*SocketConnection
+	ctx         context.Context
+	channelData *ChannelData
+}
+
+// ChannelData - Will return channel data FreeSWITCH sent in reply to connect
+func (c *ESLConnection) ChannelData() *ChannelData {
+	return c.channelData
+}
+
+// Context - Will return context that is cancelled when server shuts down. Handlers should finish once it is done
+func (c *ESLConnection) Context() context.Context {
+	return c.ctx
 }
 
 func (c *ESLConnection) process(aHandler HandlerFunc) {
@@ -26,14 +44,16 @@ func (c *ESLConnection) process(aHandler HandlerFunc) {
 	logger.Debug("Got new connection from: %s", connID)
 	defer logger.Debug("Finish connection from: %s", connID)
 
-	if err := c.Send("connect"); err != nil {
+	// process events fron Freeswitch
+	go c.handle()
+
+	reply, err := c.request(c.ctx, "connect")
+	if err != nil {
 		logger.Error(errorWhileAccepConnection, err)
 		c.Close()
 		return
 	}
-
-	// process events fron Freeswitch
-	go c.handle()
+	c.channelData = &ChannelData{Message: reply}
 
 	shouldExit := aHandler(c)
 	if shouldExit {
@@ -42,30 +62,123 @@ func (c *ESLConnection) process(aHandler HandlerFunc) {
 	c.Close()
 }
 
+// DefaultRejectCause hangup cause used for connections rejected by admission control
+const DefaultRejectCause = "NORMAL_TEMPORARY_FAILURE"
+
+// DefaultRejectTimeout limits connect and reject fallback of connections rejected by admission control
+const DefaultRejectTimeout = 5 * time.Second
+
+// ServerOptions represent outbound server options
+type ServerOptions struct {
+	// Limits every write to accepted connections. Zero means no limit
+	WriteTimeout time.Duration
+
+	// Maximum number of concurrently running handlers. Zero means no limit
+	MaxHandlers int
+	// How long connection waits for free handler when MaxHandlers are busy. Zero rejects immediately
+	QueueTimeout time.Duration
+	// Hangup cause for rejected connections. DefaultRejectCause is used when empty
+	RejectCause string
+	// Called instead of handler for rejected connections, e.g. to `respond 503`. Hangs up with RejectCause when nil
+	RejectHandler HandlerFunc
+	// Limits connect and RejectHandler of rejected connection. Its context is not cancelled by Shutdown,
+	// so rejection reaches FreeSWITCH while server is shutting down. DefaultRejectTimeout is used when zero
+	RejectTimeout time.Duration
+
+	// When set, returns writer to record accepted connection to (see NewRecordingConn). nil skips recording
+	Recorder func(conn net.Conn) io.Writer
+
+	// Size of Messages() buffer of every connection. Zero means unbuffered for OverflowBlock
+	// and DefaultEventBufferSize otherwise
+	EventBuffer int
+	// What to do when Messages() buffer of connection is full. Blocks reading by default
+	EventOverflow OverflowPolicy
+
+	// When set, server accepts TLS connections only. Set ClientAuth and ClientCAs to verify client certificates
+	TLSConfig *tls.Config
+	// Limits TLS handshake of accepted connection. DefaultTLSHandshakeTimeout is used when zero
+	TLSHandshakeTimeout time.Duration
+}
+
+// ServerStats - Admission control counters of outbound server
+type ServerStats struct {
+	// Handlers running right now
+	Active int64
+	// Connections waiting for free handler right now
+	Waiting int64
+	// Total number of connections that had to wait for free handler
+	Queued uint64
+	// Total number of connections rejected because no handler was free
+	Rejected uint64
+}
+
 // ESLServer - In case you need to start server, this Struct have it covered
 type ESLServer struct {
+	// admission counters, first for 64-bit atomic alignment
+	running  int64
+	waiting  int64
+	queued   uint64
+	rejected uint64
+
 	listener net.Listener
 	stop     chan struct{}
+	stopOnce sync.Once
+	opts     ServerOptions
+
+	// cancelled on shutdown to ask handlers to finish
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	lock     sync.Mutex
+	stopping bool
+	conns    map[*ESLConnection]struct{}
+	active   sync.WaitGroup
+
+	// handler slots, nil when MaxHandlers is not set
+	slots chan struct{}
 }
 
 // Start - Will start new outbound server
 func (s *ESLServer) Start(aListenAddress string, aHandler HandlerFunc) error {
 	logger.Info("Starting Freeswitch Outbound Server @ (address: %s) ...", aListenAddress)
 
-	var err error
-
-	s.listener, err = net.Listen("tcp", aListenAddress)
+	listener, err := net.Listen("tcp", aListenAddress)
 
 	if err != nil {
 		logger.Error(eCouldNotStartListener, err)
 		return err
 	}
 
+	if s.opts.TLSConfig != nil {
+		listener = tls.NewListener(listener, s.opts.TLSConfig)
+	}
+
+	s.lock.Lock()
+	s.listener = listener
+	s.lock.Unlock()
+
 	go s.runServer(aHandler)
 
 	return err
 }
 
+// getListener returns listener set by Start, nil before it
+func (s *ESLServer) getListener() net.Listener {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.listener
+}
+
+// Addr - Will return address server listens on, or empty string if it is not started
+func (s *ESLServer) Addr() string {
+	listener := s.getListener()
+	if listener == nil {
+		return ""
+	}
+	return listener.Addr().String()
+}
+
 func (s *ESLServer) runServer(aHandler HandlerFunc) {
 	for {
 		logger.Debug("Waiting for incoming connections")
@@ -79,24 +192,227 @@ func (s *ESLServer) runServer(aHandler HandlerFunc) {
 			}
 			return
 		}
-		conn := ESLConnection{
+		setKeepAlive(c, DefaultKeepAlivePeriod)
+
+		if s.opts.Recorder != nil {
+			if w := s.opts.Recorder(c); w != nil {
+				c = NewRecordingConn(c, w)
+			}
+		}
+
+		conn := &ESLConnection{
 			SocketConnection: newConnection(c),
+			ctx:              s.ctx,
+		}
+		conn.SetWriteTimeout(s.opts.WriteTimeout)
+		conn.setEventBuffer(s.opts.EventBuffer, s.opts.EventOverflow)
+
+		if !s.track(conn) {
+			conn.Close()
+			continue
 		}
 
-		go conn.process(aHandler)
+		go func() {
+			defer s.untrack(conn)
+
+			if err := s.handshake(conn); err != nil {
+				logger.Error(eTLSHandshake, err)
+				conn.Close()
+				return
+			}
+
+			if !s.acquire() {
+				logger.Info("Rejecting connection %s: all %d handlers are busy", conn.id, s.opts.MaxHandlers)
+				ctx, cancel := context.WithTimeout(context.Background(), s.opts.RejectTimeout)
+				defer cancel()
+
+				conn.ctx = ctx
+				conn.process(s.reject)
+				return
+			}
+			defer s.release()
+
+			conn.process(aHandler)
+		}()
 	}
 }
 
+// handshake verifies TLS peer (and its certificate when required) before connection is handled
+func (s *ESLServer) handshake(aConn *ESLConnection) error {
+	if s.opts.TLSConfig == nil {
+		return nil
+	}
+
+	timeout := s.opts.TLSHandshakeTimeout
+	if timeout <= 0 {
+		timeout = DefaultTLSHandshakeTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, timeout)
+	defer cancel()
+
+	return aConn.handshake(ctx)
+}
+
+// acquire takes handler slot, waiting up to QueueTimeout. Returns false if connection must be rejected
+func (s *ESLServer) acquire() bool {
+	if s.slots == nil {
+		return true
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		atomic.AddInt64(&s.running, 1)
+		return true
+	default:
+	}
+
+	if s.opts.QueueTimeout > 0 {
+		atomic.AddUint64(&s.queued, 1)
+		atomic.AddInt64(&s.waiting, 1)
+		defer atomic.AddInt64(&s.waiting, -1)
+
+		timer := time.NewTimer(s.opts.QueueTimeout)
+		defer timer.Stop()
+
+		select {
+		case s.slots <- struct{}{}:
+			atomic.AddInt64(&s.running, 1)
+			return true
+		case <-timer.C:
+		case <-s.ctx.Done():
+		}
+	}
+
+	atomic.AddUint64(&s.rejected, 1)
+	return false
+}
+
+func (s *ESLServer) release() {
+	if s.slots == nil {
+		return
+	}
+
+	atomic.AddInt64(&s.running, -1)
+	<-s.slots
+}
+
+// reject is handler for connections that didn't get handler slot
+func (s *ESLServer) reject(aConn *ESLConnection) bool {
+	if s.opts.RejectHandler != nil {
+		return s.opts.RejectHandler(aConn)
+	}
+
+	if err := aConn.ExecuteHangup("", s.opts.RejectCause, false); err != nil {
+		logger.Error("Got error while rejecting connection: %s", err)
+	}
+	return true
+}
+
+// Stats - Will return admission control counters
+func (s *ESLServer) Stats() ServerStats {
+	return ServerStats{
+		Active:   atomic.LoadInt64(&s.running),
+		Waiting:  atomic.LoadInt64(&s.waiting),
+		Queued:   atomic.LoadUint64(&s.queued),
+		Rejected: atomic.LoadUint64(&s.rejected),
+	}
+}
+
+// track registers active connection. Returns false when server is shutting down
+func (s *ESLServer) track(aConn *ESLConnection) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.stopping {
+		return false
+	}
+
+	s.conns[aConn] = struct{}{}
+	s.active.Add(1)
+	return true
+}
+
+func (s *ESLServer) untrack(aConn *ESLConnection) {
+	s.lock.Lock()
+	delete(s.conns, aConn)
+	s.lock.Unlock()
+
+	s.active.Done()
+}
+
+// closeListener stops accepting new connections
+func (s *ESLServer) closeListener() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+		if listener := s.getListener(); listener != nil {
+			listener.Close()
+		}
+	})
+}
+
 // Stop - Will close server connection once SIGTERM/Interrupt is received
 func (s *ESLServer) Stop() {
 	logger.Debug("Stopping Outbound Server ...")
-	close(s.stop)
-	s.listener.Close()
+	s.closeListener()
+}
+
+// Shutdown - Will stop accepting connections, cancel handlers context and wait for active handlers to finish.
+// Connections still active when ctx is done are closed and ctx error is returned.
+func (s *ESLServer) Shutdown(ctx context.Context) error {
+	logger.Debug("Shutting down Outbound Server ...")
+	s.closeListener()
+
+	s.lock.Lock()
+	s.stopping = true
+	s.lock.Unlock()
+
+	s.cancel()
+
+	finished := make(chan struct{})
+	go func() {
+		s.active.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.lock.Lock()
+	for conn := range s.conns {
+		logger.Debug("Force closing connection: %s", conn.id)
+		conn.Close()
+	}
+	s.lock.Unlock()
+
+	return ctx.Err()
 }
 
 // NewESLServer - Will instanciate new outbound server
 func NewESLServer() *ESLServer {
-	return &ESLServer{
-		stop: make(chan struct{}),
+	return NewESLServerWithOptions(ServerOptions{})
+}
+
+// NewESLServerWithOptions - Will instanciate new outbound server with specified options
+func NewESLServerWithOptions(aOpts ServerOptions) *ESLServer {
+	if aOpts.RejectCause == "" {
+		aOpts.RejectCause = DefaultRejectCause
+	}
+	if aOpts.RejectTimeout <= 0 {
+		aOpts.RejectTimeout = DefaultRejectTimeout
+	}
+
+	s := &ESLServer{
+		stop:  make(chan struct{}),
+		opts:  aOpts,
+		conns: make(map[*ESLConnection]struct{}),
+	}
+	if aOpts.MaxHandlers > 0 {
+		s.slots = make(chan struct{}, aOpts.MaxHandlers)
 	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	return s
 }
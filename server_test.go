@@ -0,0 +1,246 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/PSyton/goesl"
+	"github.com/PSyton/goesl/goesltest"
+)
+
+// dialCall connects fake channel to outbound server
+func dialCall(t *testing.T, srv *goesl.ESLServer, uuid string) *goesltest.Call {
+	t.Helper()
+
+	call, err := goesltest.DialOutbound(srv.Addr(), map[string]string{"Unique-ID": uuid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return call
+}
+
+// dialQueuedCall connects fake channel in background, because DialOutbound returns only after server
+// sends connect, i.e. once connection leaves the queue
+func dialQueuedCall(t *testing.T, srv *goesl.ESLServer, uuid string) <-chan *goesltest.Call {
+	result := make(chan *goesltest.Call, 1)
+	go func() {
+		call, err := goesltest.DialOutbound(srv.Addr(), map[string]string{"Unique-ID": uuid})
+		if err != nil {
+			t.Error(err)
+			close(result)
+			return
+		}
+		result <- call
+	}()
+	return result
+}
+
+// receiveCall fails unless queued call is connected in time
+func receiveCall(t *testing.T, calls <-chan *goesltest.Call) *goesltest.Call {
+	t.Helper()
+
+	select {
+	case call, ok := <-calls:
+		if !ok {
+			t.FailNow()
+		}
+		return call
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued call wasn't connected")
+	}
+	return nil
+}
+
+// waitCallDone fails unless server side finishes the call in time
+func waitCallDone(t *testing.T, call *goesltest.Call) {
+	t.Helper()
+
+	select {
+	case <-call.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("call %s wasn't finished", call.UUID())
+	}
+}
+
+// waitStats fails unless server stats satisfy the condition in time
+func waitStats(t *testing.T, srv *goesl.ESLServer, cond func(goesl.ServerStats) bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond(srv.Stats()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected stats %+v", srv.Stats())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func expectHangup(t *testing.T, call *goesltest.Call, cause string) {
+	t.Helper()
+
+	executions := call.Executions()
+	if len(executions) != 1 || executions[0].App != "hangup" || executions[0].Args != cause {
+		t.Fatalf("expected hangup with %s, got %+v", cause, executions)
+	}
+}
+
+func TestServerQueuesConnectionsForFreeHandler(t *testing.T) {
+	release := make(chan struct{})
+	handled := make(chan string, 2)
+	srv := serveOutbound(t, goesl.ServerOptions{MaxHandlers: 1, QueueTimeout: 5 * time.Second}, func(conn *goesl.ESLConnection) bool {
+		handled <- conn.ChannelData().UniqueID()
+		<-release
+		return true
+	})
+	defer srv.Stop()
+
+	first := dialCall(t, srv, "first")
+	defer first.Close()
+	if uuid := <-handled; uuid != "first" {
+		t.Fatalf("expected first call handled, got %s", uuid)
+	}
+
+	calls := dialQueuedCall(t, srv, "second")
+	waitStats(t, srv, func(stats goesl.ServerStats) bool {
+		return stats.Active == 1 && stats.Waiting == 1 && stats.Queued == 1
+	})
+
+	close(release)
+	second := receiveCall(t, calls)
+	defer second.Close()
+	if uuid := <-handled; uuid != "second" {
+		t.Fatalf("expected queued call handled, got %s", uuid)
+	}
+	waitCallDone(t, first)
+	waitCallDone(t, second)
+
+	if stats := srv.Stats(); stats.Rejected != 0 {
+		t.Fatalf("expected no rejected calls, got %+v", stats)
+	}
+}
+
+func TestServerRejectsWhenHandlersAreBusy(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{}, 1)
+	srv := serveOutbound(t, goesl.ServerOptions{MaxHandlers: 1, RejectCause: "USER_BUSY"}, func(conn *goesl.ESLConnection) bool {
+		started <- struct{}{}
+		<-release
+		return true
+	})
+	defer srv.Stop()
+
+	first := dialCall(t, srv, "first")
+	defer first.Close()
+	<-started
+
+	rejected := dialCall(t, srv, "rejected")
+	defer rejected.Close()
+	waitCallDone(t, rejected)
+
+	expectHangup(t, rejected, "USER_BUSY")
+	if stats := srv.Stats(); stats.Rejected != 1 || stats.Queued != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestServerRejectHandler(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{}, 1)
+	srv := serveOutbound(t, goesl.ServerOptions{
+		MaxHandlers: 1,
+		RejectHandler: func(conn *goesl.ESLConnection) bool {
+			if err := conn.Execute("respond", "503", true); err != nil {
+				t.Errorf("respond: %v", err)
+			}
+			return true
+		},
+	}, func(conn *goesl.ESLConnection) bool {
+		started <- struct{}{}
+		<-release
+		return true
+	})
+	defer srv.Stop()
+
+	first := dialCall(t, srv, "first")
+	defer first.Close()
+	<-started
+
+	rejected := dialCall(t, srv, "rejected")
+	defer rejected.Close()
+	waitCallDone(t, rejected)
+
+	if executions := rejected.Executions(); len(executions) != 1 || executions[0].App != "respond" || executions[0].Args != "503" {
+		t.Fatalf("expected respond 503, got %+v", executions)
+	}
+}
+
+func TestShutdownRejectsQueuedConnections(t *testing.T) {
+	srv := serveOutbound(t, goesl.ServerOptions{MaxHandlers: 1, QueueTimeout: time.Minute}, func(conn *goesl.ESLConnection) bool {
+		<-conn.Context().Done()
+		return true
+	})
+	defer srv.Stop()
+
+	active := dialCall(t, srv, "active")
+	defer active.Close()
+	waitStats(t, srv, func(stats goesl.ServerStats) bool {
+		return stats.Active == 1
+	})
+
+	calls := dialQueuedCall(t, srv, "queued")
+	waitStats(t, srv, func(stats goesl.ServerStats) bool {
+		return stats.Waiting == 1
+	})
+
+	ctx, cancel := testContext()
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	queued := receiveCall(t, calls)
+	defer queued.Close()
+	waitCallDone(t, active)
+	waitCallDone(t, queued)
+	expectHangup(t, queued, goesl.DefaultRejectCause)
+	if stats := srv.Stats(); stats.Rejected != 1 || stats.Active != 0 || stats.Waiting != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	if _, err := goesltest.DialOutbound(srv.Addr(), nil); err == nil {
+		t.Fatal("expected server to stop accepting connections")
+	}
+}
+
+func TestShutdownClosesHandlersThatDontFinish(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	srv := serveOutbound(t, goesl.ServerOptions{}, func(conn *goesl.ESLConnection) bool {
+		<-release
+		return true
+	})
+	defer srv.Stop()
+
+	call := dialCall(t, srv, "stuck")
+	defer call.Close()
+	waitStats(t, srv, func(stats goesl.ServerStats) bool {
+		return stats.Active == 0
+	})
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	waitCallDone(t, call)
+}
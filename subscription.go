@@ -0,0 +1,276 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventFormat - Format FreeSWITCH uses to deliver events to the connection
+type EventFormat string
+
+// Supported event formats
+const (
+	EventFormatPlain EventFormat = "plain"
+	EventFormatJSON  EventFormat = "json"
+	EventFormatXML   EventFormat = "xml"
+)
+
+// EventFilter - Single filter applied with AddFilter
+type EventFilter struct {
+	Header string
+	Value  string
+}
+
+// Subscriptions - Snapshot of events, filters and modes successfully applied to the connection
+type Subscriptions struct {
+	Format       EventFormat
+	Events       []string
+	Filters      []EventFilter
+	DivertEvents bool
+	MyEvents     bool
+	MyEventsUUID string
+	Linger       bool
+	LingerTime   time.Duration
+}
+
+func (s Subscriptions) clone() Subscriptions {
+	s.Events = append([]string(nil), s.Events...)
+	s.Filters = append([]EventFilter(nil), s.Filters...)
+	return s
+}
+
+// Subscriptions - Will return copy of currently active subscriptions
+func (c *SocketConnection) Subscriptions() Subscriptions {
+	c.subLock.Lock()
+	defer c.subLock.Unlock()
+
+	return c.subscriptions.clone()
+}
+
+func (c *SocketConnection) updateSubscriptions(aUpdate func(*Subscriptions)) {
+	c.subLock.Lock()
+	defer c.subLock.Unlock()
+
+	aUpdate(&c.subscriptions)
+}
+
+// Subscribe - Will send `event <format> <events...>` and wait for reply
+func (c *SocketConnection) Subscribe(ctx context.Context, format EventFormat, events ...string) error {
+	if len(events) == 0 {
+		return newErrorInvalidCommand("event " + string(format))
+	}
+
+	if _, err := c.request(ctx, "event "+string(format)+" "+strings.Join(events, " ")); err != nil {
+		return err
+	}
+
+	c.updateSubscriptions(func(s *Subscriptions) {
+		s.Format = format
+		for _, e := range events {
+			if !StringInSlice(e, s.Events) {
+				s.Events = append(s.Events, e)
+			}
+		}
+	})
+	return nil
+}
+
+// Unsubscribe - Will send `nixevent <events...>` and wait for reply
+func (c *SocketConnection) Unsubscribe(ctx context.Context, events ...string) error {
+	if len(events) == 0 {
+		return newErrorInvalidCommand("nixevent")
+	}
+
+	if _, err := c.request(ctx, "nixevent "+strings.Join(events, " ")); err != nil {
+		return err
+	}
+
+	c.updateSubscriptions(func(s *Subscriptions) {
+		kept := s.Events[:0]
+		for _, e := range s.Events {
+			if !StringInSlice(e, events) {
+				kept = append(kept, e)
+			}
+		}
+		s.Events = kept
+	})
+	return nil
+}
+
+// UnsubscribeAll - Will send `noevents` and wait for reply
+func (c *SocketConnection) UnsubscribeAll(ctx context.Context) error {
+	if _, err := c.request(ctx, "noevents"); err != nil {
+		return err
+	}
+
+	c.updateSubscriptions(func(s *Subscriptions) {
+		s.Events = nil
+	})
+	return nil
+}
+
+// AddFilter - Will send `filter <header> <value>` and wait for reply
+func (c *SocketConnection) AddFilter(ctx context.Context, header, value string) error {
+	if _, err := c.request(ctx, "filter "+header+" "+value); err != nil {
+		return err
+	}
+
+	c.updateSubscriptions(func(s *Subscriptions) {
+		f := EventFilter{Header: header, Value: value}
+		for _, existing := range s.Filters {
+			if existing == f {
+				return
+			}
+		}
+		s.Filters = append(s.Filters, f)
+	})
+	return nil
+}
+
+// DeleteFilter - Will send `filter delete <header> <value>` and wait for reply.
+// Empty value removes all filters for the header.
+func (c *SocketConnection) DeleteFilter(ctx context.Context, header, value string) error {
+	cmd := "filter delete " + header
+	if value != "" {
+		cmd += " " + value
+	}
+
+	if _, err := c.request(ctx, cmd); err != nil {
+		return err
+	}
+
+	c.updateSubscriptions(func(s *Subscriptions) {
+		kept := s.Filters[:0]
+		for _, f := range s.Filters {
+			if f.Header != header || (value != "" && f.Value != value) {
+				kept = append(kept, f)
+			}
+		}
+		s.Filters = kept
+	})
+	return nil
+}
+
+// DivertEvents - Will send `divert_events on|off` and wait for reply
+func (c *SocketConnection) DivertEvents(ctx context.Context, on bool) error {
+	cmd := "divert_events off"
+	if on {
+		cmd = "divert_events on"
+	}
+
+	if _, err := c.request(ctx, cmd); err != nil {
+		return err
+	}
+
+	c.updateSubscriptions(func(s *Subscriptions) {
+		s.DivertEvents = on
+	})
+	return nil
+}
+
+// MyEvents - Will send `myevents [uuid]` and wait for reply. Outbound connections may pass empty uuid
+func (c *SocketConnection) MyEvents(ctx context.Context, uuid string) error {
+	cmd := "myevents"
+	if uuid != "" {
+		cmd += " " + uuid
+	}
+
+	if _, err := c.request(ctx, cmd); err != nil {
+		return err
+	}
+
+	c.updateSubscriptions(func(s *Subscriptions) {
+		s.MyEvents = true
+		s.MyEventsUUID = uuid
+	})
+	return nil
+}
+
+// Linger - Will send `linger [seconds]` and wait for reply. Zero duration uses FreeSWITCH default
+func (c *SocketConnection) Linger(ctx context.Context, duration time.Duration) error {
+	cmd := "linger"
+	if seconds := int(duration / time.Second); seconds > 0 {
+		cmd += " " + strconv.Itoa(seconds)
+	}
+
+	if _, err := c.request(ctx, cmd); err != nil {
+		return err
+	}
+
+	c.updateSubscriptions(func(s *Subscriptions) {
+		s.Linger = true
+		s.LingerTime = duration
+	})
+	return nil
+}
+
+// NoLinger - Will send `nolinger` and wait for reply
+func (c *SocketConnection) NoLinger(ctx context.Context) error {
+	if _, err := c.request(ctx, "nolinger"); err != nil {
+		return err
+	}
+
+	c.updateSubscriptions(func(s *Subscriptions) {
+		s.Linger = false
+		s.LingerTime = 0
+	})
+	return nil
+}
+
+// restoreSubscriptions re-applies subscriptions snapshot, e.g. taken from the previous connection.
+// Commands FreeSWITCH rejects are skipped, so they are not part of Subscriptions() anymore, and the first
+// rejection is returned as rejected. Any other error stops restoring
+func (c *SocketConnection) restoreSubscriptions(ctx context.Context, aSubs Subscriptions) (rejected error, err error) {
+	// apply keeps going after rejected command
+	apply := func(aErr error) error {
+		if errors.Is(aErr, ErrCommandRejected) {
+			logger.Error("Dropping subscription FreeSWITCH rejected: %s", aErr)
+			if rejected == nil {
+				rejected = aErr
+			}
+			return nil
+		}
+		return aErr
+	}
+
+	if len(aSubs.Events) > 0 {
+		if err := apply(c.Subscribe(ctx, aSubs.Format, aSubs.Events...)); err != nil {
+			return rejected, err
+		}
+	}
+
+	for _, f := range aSubs.Filters {
+		if err := apply(c.AddFilter(ctx, f.Header, f.Value)); err != nil {
+			return rejected, err
+		}
+	}
+
+	if aSubs.DivertEvents {
+		if err := apply(c.DivertEvents(ctx, true)); err != nil {
+			return rejected, err
+		}
+	}
+
+	if aSubs.MyEvents {
+		if err := apply(c.MyEvents(ctx, aSubs.MyEventsUUID)); err != nil {
+			return rejected, err
+		}
+	}
+
+	if aSubs.Linger {
+		if err := apply(c.Linger(ctx, aSubs.LingerTime)); err != nil {
+			return rejected, err
+		}
+	}
+
+	return rejected, nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+)
+
+// DefaultTLSHandshakeTimeout limits TLS handshake of accepted connections
+const DefaultTLSHandshakeTimeout = 10 * time.Second
+
+// findTLSConn looks for TLS connection under wrappers added by the library
+func findTLSConn(c net.Conn) (*tls.Conn, bool) {
+	for {
+		if tlsConn, ok := c.(*tls.Conn); ok {
+			return tlsConn, true
+		}
+
+		wrapper, ok := c.(interface{ underlying() net.Conn })
+		if !ok {
+			return nil, false
+		}
+		c = wrapper.underlying()
+	}
+}
+
+// clientTLSConfig fills ServerName from dialed host when it is not set
+func clientTLSConfig(aConfig *tls.Config, aHost string) *tls.Config {
+	if aConfig.ServerName != "" || aConfig.InsecureSkipVerify {
+		return aConfig
+	}
+
+	cfg := aConfig.Clone()
+	cfg.ServerName = aHost
+	return cfg
+}
+
+// handshake completes TLS handshake if connection is TLS one, so failures are reported before ESL traffic
+func (c *SocketConnection) handshake(ctx context.Context) error {
+	tlsConn, ok := findTLSConn(c.connection)
+	if !ok {
+		return nil
+	}
+
+	stop := watchContext(ctx, time.Time{}, c.connection.SetDeadline)
+	defer stop()
+
+	return contextError(ctx, tlsConn.Handshake())
+}
@@ -6,6 +6,11 @@
 
 package goesl
 
+import (
+	cryptorand "crypto/rand"
+	"fmt"
+)
+
 // StringInSlice - Will check if string in list. This is equivalent to python if x in []
 // @TODO - What the fuck Nevio...
 func StringInSlice(str string, list []string) bool {
@@ -16,3 +21,16 @@ func StringInSlice(str string, list []string) bool {
 	}
 	return false
 }
+
+// newUUID - Will return random (version 4) UUID string. Used to tag commands like bgapi Job-UUID.
+func newUUID() string {
+	var b [16]byte
+	if _, err := cryptorand.Read(b[:]); err != nil {
+		return getULID()
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
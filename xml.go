@@ -0,0 +1,68 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/textproto"
+)
+
+// parseXMLEvent extracts headers and body from text/event-xml payload:
+//
+//	<event><headers><Event-Name>HEARTBEAT</Event-Name>...</headers><body>...</body></event>
+//
+// Header values are returned as sent (url-encoded), so they go through copyHeaders like plain events.
+func parseXMLEvent(aData []byte) (textproto.MIMEHeader, []byte, error) {
+	hdr := make(textproto.MIMEHeader)
+	var body []byte
+
+	decoder := xml.NewDecoder(bytes.NewReader(aData))
+	var path []string
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			path = append(path, t.Name.Local)
+			if len(path) == 3 && path[1] == "headers" {
+				var value string
+				if err := decoder.DecodeElement(&value, &t); err != nil {
+					return nil, nil, err
+				}
+				// DecodeElement consumed the end element
+				path = path[:len(path)-1]
+				hdr[t.Name.Local] = []string{value}
+			} else if len(path) == 2 && t.Name.Local == "body" {
+				var value string
+				if err := decoder.DecodeElement(&value, &t); err != nil {
+					return nil, nil, err
+				}
+				path = path[:len(path)-1]
+				body = []byte(value)
+			}
+		case xml.EndElement:
+			if len(path) > 0 {
+				path = path[:len(path)-1]
+			}
+		}
+	}
+
+	if body == nil {
+		body = make([]byte, 0)
+	}
+
+	return hdr, body, nil
+}
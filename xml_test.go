@@ -0,0 +1,62 @@
+// Copyright 2015 Nevio Vesic
+// Please check out LICENSE file for more information about what you CAN and what you CANNOT do!
+// Basically in short this is a free software for you to do whatever you want to do BUT copyright must be included!
+// I didn't write all of this code so you could say it's yours.
+// MIT License
+
+package goesl_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PSyton/goesl"
+)
+
+func TestXMLEvents(t *testing.T) {
+	server, client := startClient(t, nil)
+	defer server.Close()
+	defer client.Close()
+
+	ctx, cancel := testContext()
+	defer cancel()
+
+	if err := client.Subscribe(ctx, goesl.EventFormatXML, "ALL"); err != nil {
+		t.Fatal(err)
+	}
+
+	headers := map[string]string{
+		"Event-Name":            "CUSTOM",
+		"Event-Subclass":        "sofia::register",
+		"Unique-ID":             "uuid-1",
+		"variable_sip_from":     "<sip:1000@example.com>;tag=a&b",
+		"Caller-Caller-ID-Name": "John Doe",
+	}
+	if err := server.PushEvent(goesl.EventFormatXML, headers, "<b>markup & text</b>"); err != nil {
+		t.Fatal(err)
+	}
+
+	var msg *goesl.Message
+	select {
+	case msg = <-client.Messages():
+	case <-time.After(5 * time.Second):
+		t.Fatal("event wasn't delivered")
+	}
+
+	ev, err := msg.AsEvent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Name() != goesl.EventCustom || ev.Subclass() != "sofia::register" || ev.UniqueID() != "uuid-1" {
+		t.Fatalf("unexpected event %s", ev)
+	}
+	if got := ev.GetHeader("Variable_sip_from"); got != "<sip:1000@example.com>;tag=a&b" {
+		t.Errorf("expected decoded header, got %q", got)
+	}
+	if got := ev.GetHeader("Caller-Caller-Id-Name"); got != "John Doe" {
+		t.Errorf("expected decoded caller name, got %q", got)
+	}
+	if got := string(ev.Body); got != "<b>markup & text</b>" {
+		t.Errorf("expected body, got %q", got)
+	}
+}